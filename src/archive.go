@@ -0,0 +1,237 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const defaultMaxEntrySize = 10 << 20 // max bytes of archive entry
+
+var (
+	archiveExts = []string{".tar", ".tar.gz", ".tgz", ".zip"}
+
+	// extensions of archive entries which are not html
+	nonHTMLExts = map[string]bool{
+		".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".svg": true, ".ico": true,
+		".css": true, ".js": true, ".json": true, ".xml": true, ".pdf": true, ".txt": true, ".md": true,
+		".zip": true, ".gz": true, ".tgz": true, ".tar": true, ".mp3": true, ".mp4": true,
+		".woff": true, ".woff2": true, ".ttf": true, ".exe": true, ".bin": true,
+	}
+)
+
+// isArchive return true if file name has archive extension
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitArchive split path like data.tar.gz/en/0 to archive file and folder inside it,
+// archive is empty if path is not in archive
+func splitArchive(p string) (archive, inner string) {
+	for dir := filepath.Clean(p); ; dir = filepath.Dir(dir) {
+		if isArchive(dir) {
+			if st, err := os.Stat(dir); err == nil && !st.IsDir() {
+				inner = strings.Trim(filepath.ToSlash(strings.TrimPrefix(filepath.Clean(p), dir)), "/")
+				return dir, inner
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return "", ""
+		}
+	}
+}
+
+// hasArticles return true if dir or archive with dir exists
+func hasArticles(dir string) bool {
+	if archive, _ := splitArchive(dir); archive != "" {
+		return true
+	}
+	_, err := os.Stat(dir)
+	return err == nil
+}
+
+// entryName return archive relative path of entry, false if entry is outside of folder
+func entryName(name, folder string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if folder == "" || name == folder || strings.HasPrefix(name, folder+"/") {
+		return name, name != ""
+	}
+	return name, false
+}
+
+// archiveEntry regular file in archive
+type archiveEntry struct {
+	name string
+	size int64
+	open func() (io.ReadCloser, error)
+}
+
+// archiveArticles return articles of files in archive folder with content,
+// entries are streamed from archive without extracting. Exits if archive can not be opened
+func archiveArticles(archive, folder string) <-chan Article {
+	next, closeArchive, err := openArchive(archive)
+	if err != nil {
+		checkErr(fmt.Errorf("%s: %v", archive, err))
+	}
+	maxSize := int64(optInt("max-entry-size", defaultMaxEntrySize))
+	out := make(chan Article)
+	go func() {
+		defer close(out)
+		defer closeArchive()
+		for {
+			e, err := next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				println(archive, err.Error())
+				return
+			}
+			name, ok := entryName(e.name, folder)
+			if !ok || !maybeHTML(name) {
+				continue
+			}
+			if e.size > maxSize {
+				println(archive, name, "skipped, size", e.size, "is over --max-entry-size")
+				continue
+			}
+			b, err := readEntry(e, maxSize)
+			if err != nil {
+				println(archive, name, err.Error())
+				continue
+			}
+			out <- Article{Name: name, File: filepath.Join(archive, name), content: b}
+		}
+	}()
+	return out
+}
+
+// readEntry read entry content up to max bytes
+func readEntry(e archiveEntry, max int64) ([]byte, error) {
+	r, err := e.open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err == nil && int64(len(b)) > max {
+		err = fmt.Errorf("skipped, size is over --max-entry-size")
+	}
+	return b, err
+}
+
+// maybeHTML return false for hidden files and files with extensions of other types
+func maybeHTML(name string) bool {
+	base := path.Base(name)
+	if strings.HasPrefix(base, ".") || strings.HasPrefix(name, "__MACOSX/") {
+		return false
+	}
+	return !nonHTMLExts[strings.ToLower(path.Ext(base))]
+}
+
+// openArchive return function returning next regular file of archive, io.EOF after last one
+func openArchive(archive string) (next func() (archiveEntry, error), closeArchive func() error, err error) {
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		return openZip(archive)
+	}
+	return openTar(archive)
+}
+
+func openTar(archive string) (func() (archiveEntry, error), func() error, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r io.Reader = f
+	if lower := strings.ToLower(archive); strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	next := func() (archiveEntry, error) {
+		for {
+			h, err := tr.Next()
+			if err != nil {
+				return archiveEntry{}, err
+			}
+			if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeRegA {
+				continue
+			}
+			open := func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(tr), nil
+			}
+			return archiveEntry{name: h.Name, size: h.Size, open: open}, nil
+		}
+	}
+	return next, f.Close, nil
+}
+
+func openZip(archive string) (func() (archiveEntry, error), func() error, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := 0
+	next := func() (archiveEntry, error) {
+		for ; i < len(zr.File); i++ {
+			zf := zr.File[i]
+			if zf.FileInfo().IsDir() {
+				continue
+			}
+			i++
+			return archiveEntry{name: zf.Name, size: int64(zf.UncompressedSize64), open: zf.Open}, nil
+		}
+		return archiveEntry{}, io.EOF
+	}
+	return next, zr.Close, nil
+}
+
+// readArticle return content of article file or archive entry,
+// entry is read from archive again if content is already released
+func readArticle(a Article) ([]byte, error) {
+	if a.content != nil {
+		return a.content, nil
+	}
+	if archive, name := splitArchive(a.File); archive != "" {
+		return readArchiveEntry(archive, name)
+	}
+	return ioutil.ReadFile(a.File)
+}
+
+// readArchiveEntry return content of entry with name in archive
+func readArchiveEntry(archive, name string) ([]byte, error) {
+	next, closeArchive, err := openArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer closeArchive()
+	for {
+		e, err := next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s: entry %s not found", archive, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry, _ := entryName(e.name, ""); entry == name {
+			return readEntry(e, int64(optInt("max-entry-size", defaultMaxEntrySize)))
+		}
+	}
+}
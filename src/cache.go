@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// parserVersion is part of cache key, increase it when parsing changes.
+// Words are not cached, they depend on stop words and are tokenized on each run
+const parserVersion = 5
+
+// ArticleCache parsed articles in dir keyed by hash of parser version and file content
+type ArticleCache struct {
+	dir string
+}
+
+// cache of parsed articles in --cache dir, disabled by default
+var cache = &ArticleCache{}
+
+// initCache set cache dir from --cache option
+func initCache() {
+	cache.dir = optString("cache", "")
+}
+
+// Key return cache key of file content
+func (c *ArticleCache) Key(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "tgnews parser %d\n", parserVersion)
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *ArticleCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// Get return cached article for key
+func (c *ArticleCache) Get(key string) (a Article, ok bool) {
+	if c.dir == "" {
+		return
+	}
+	b, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return
+	}
+	return a, json.Unmarshal(b, &a) == nil
+}
+
+// Put save article for key, file is renamed in place so parallel workers never see partial records
+func (c *ArticleCache) Put(key string, a Article) error {
+	if c.dir == "" {
+		return nil
+	}
+	file := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), key)
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const charsetSniffLen = 16384 // bytes of html searched for meta charset
+
+var (
+	// <meta charset="..."> and <meta http-equiv="Content-Type" content="text/html; charset=...">
+	metaCharset = regexp.MustCompile(`(?i)<meta[^>]*?charset\s*=\s*["']?\s*([-\w.:]+)`)
+
+	charsetAliases = map[string]string{
+		"utf-8": "utf-8", "utf8": "utf-8",
+		"windows-1251": "windows-1251", "cp1251": "windows-1251", "win-1251": "windows-1251",
+		"x-cp1251": "windows-1251", "win1251": "windows-1251",
+		"koi8-r": "koi8-r", "koi8r": "koi8-r", "koi8-u": "koi8-r", "cskoi8r": "koi8-r",
+	}
+
+	// runes of bytes 0x80-0xff
+	charsetTables = map[string][]rune{
+		"windows-1251": []rune("ЂЃ‚ѓ„…†‡€‰Љ‹ЊЌЋЏђ‘’“”•–—\ufffd™љ›њќћџ" +
+			"\u00a0ЎўЈ¤Ґ¦§Ё©Є«¬\u00ad®Ї°±Ііґµ¶·ё№є»јЅѕї" +
+			"АБВГДЕЖЗИЙКЛМНОПРСТУФХЦЧШЩЪЫЬЭЮЯ" +
+			"абвгдежзийклмнопрстуфхцчшщъыьэюя"),
+		"koi8-r": []rune("─│┌┐└┘├┤┬┴┼▀▄█▌▐░▒▓⌠■∙√≈≤≥\u00a0⌡°²·÷" +
+			"═║╒ё╓╔╕╖╗╘╙╚╛╜╝╞╟╠╡Ё╢╣╤╥╦╧╨╩╪╫╬©" +
+			"юабцдефгхийклмнопярстужвьызшэщчъ" +
+			"ЮАБЦДЕФГХИЙКЛМНОПЯРСТУЖВЬЫЗШЭЩЧЪ"),
+	}
+)
+
+const cyrillicShare = 0.8 // min share of cyrillic letters in bytes above 0x7f to guess legacy charset
+
+// detectCharset return charset of html: utf-8 if content is valid or mostly valid utf-8,
+// otherwise charset from meta tag or guessed by bytes, empty if unknown.
+// Declared utf-8 of content which is not utf-8 is guessed by bytes
+func detectCharset(b []byte) string {
+	if utf8.Valid(b) || mostlyUTF8(b) {
+		return "utf-8"
+	}
+	head := b
+	if len(head) > charsetSniffLen {
+		head = head[:charsetSniffLen]
+	}
+	if m := metaCharset.FindSubmatch(head); m != nil {
+		name := strings.ToLower(string(m[1]))
+		cs, ok := charsetAliases[name]
+		if !ok {
+			return name
+		}
+		if cs == "utf-8" {
+			if guess := guessCharset(b); guess != "" {
+				return guess
+			}
+		}
+		return cs
+	}
+	return guessCharset(b)
+}
+
+// mostlyUTF8 return true if valid multibyte runes outnumber invalid bytes
+func mostlyUTF8(b []byte) bool {
+	valid, invalid := 0, 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		switch {
+		case r == utf8.RuneError && size == 1:
+			invalid++
+		case size > 1:
+			valid++
+		}
+		b = b[size:]
+	}
+	return valid > invalid
+}
+
+// isCyrillicByte return true if byte is cyrillic letter in windows-1251 or koi8-r
+func isCyrillicByte(c byte) bool {
+	return c >= 0xc0 || c == 0xa8 || c == 0xb8 || c == 0xa3 || c == 0xb3
+}
+
+// guessCharset return windows-1251 or koi8-r if bytes above 0x7f are mostly cyrillic
+// letters next to each other, as in words, empty otherwise. Single letters between
+// ascii, like é in café, are latin. Charset is chosen by case of letters,
+// lowercase letters are 0xe0-0xff in windows-1251 and 0xc0-0xdf in koi8-r
+func guessCharset(b []byte) string {
+	high, letters, paired := 0, 0, 0
+	c0, e0 := 0, 0 // letters in 0xc0-0xdf and 0xe0-0xff
+	for i, c := range b {
+		if c < 0x80 {
+			continue
+		}
+		high++
+		if !isCyrillicByte(c) {
+			continue
+		}
+		letters++
+		if (i > 0 && isCyrillicByte(b[i-1])) || (i+1 < len(b) && isCyrillicByte(b[i+1])) {
+			paired++
+		}
+		switch {
+		case c >= 0xe0:
+			e0++
+		case c >= 0xc0:
+			c0++
+		}
+	}
+	if letters == 0 || float64(letters) < cyrillicShare*float64(high) ||
+		float64(paired) < cyrillicShare*float64(letters) {
+		return ""
+	}
+	if c0 > e0 {
+		return "koi8-r"
+	}
+	return "windows-1251"
+}
+
+// toUTF8 return html transcoded to utf-8 and its source charset, charset is empty
+// if html is not transcoded. Invalid bytes of utf-8 html are dropped
+func toUTF8(b []byte) ([]byte, string) {
+	cs := detectCharset(b)
+	table, ok := charsetTables[cs]
+	if !ok {
+		if cs == "utf-8" && !utf8.Valid(b) {
+			return []byte(strings.ToValidUTF8(string(b), "")), ""
+		}
+		return b, ""
+	}
+	res := make([]byte, 0, len(b)*2)
+	for _, c := range b {
+		if c < 0x80 {
+			res = append(res, c)
+			continue
+		}
+		res = append(res, string(table[c-0x80])...)
+	}
+	return res, cs
+}
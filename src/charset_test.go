@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	charsetText = "Сегодня в Москве прошла встреча"
+	cp1251Text  = "\xd1\xe5\xe3\xee\xe4\xed\xff\x20\xe2\x20\xcc\xee\xf1\xea\xe2\xe5\x20\xef\xf0\xee\xf8\xeb\xe0\x20\xe2\xf1\xf2\xf0\xe5\xf7\xe0"
+	koi8Text    = "\xf3\xc5\xc7\xcf\xc4\xce\xd1\x20\xd7\x20\xed\xcf\xd3\xcb\xd7\xc5\x20\xd0\xd2\xcf\xdb\xcc\xc1\x20\xd7\xd3\xd4\xd2\xc5\xde\xc1"
+)
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		text    string // expected in result
+		charset string
+		bad     string // mojibake not expected in result
+	}{
+		{"utf-8", `<html><meta charset="utf-8"><p>` + charsetText + `</p></html>`, charsetText, "", ""},
+		{"cp1251 meta", `<html><meta charset="windows-1251"><p>` + cp1251Text + `</p></html>`, charsetText, "windows-1251", ""},
+		{"cp1251 guess", `<html><p>` + cp1251Text + `</p></html>`, charsetText, "windows-1251", ""},
+		{"koi8-r http-equiv", `<html><meta http-equiv="Content-Type" content="text/html; charset=KOI8-R"><p>` +
+			koi8Text + `</p></html>`, charsetText, "koi8-r", ""},
+		{"koi8-r guess", `<html><p>` + koi8Text + `</p></html>`, charsetText, "koi8-r", ""},
+		{"stray byte declared utf-8", `<html><meta charset="utf-8"><p>Привет мир</p>` + "\xff" + `</html>`, "Привет мир", "", "п╫"},
+		{"cp1251 declared utf-8", `<html><meta charset="utf-8"><p>` + cp1251Text + `</p></html>`, charsetText, "windows-1251", ""},
+		{"stray byte", `<html><p>` + charsetText + "\xff" + `</p></html>`, charsetText, "", "я│"},
+		{"latin-1", "<html><p>Un caf\xe9 au lait, s'il vous pla\xeet</p></html>", "Un caf", "", "й"},
+		{"latin-1 declared", "<html><meta charset=\"iso-8859-1\"><p>caf\xe9 cr\xe8me</p></html>", "caf", "", "й"},
+	}
+	for _, tt := range tests {
+		b, charset := toUTF8([]byte(tt.html))
+		if charset != tt.charset {
+			t.Errorf("%s: charset %q, want %q", tt.name, charset, tt.charset)
+		}
+		if !strings.Contains(string(b), tt.text) {
+			t.Errorf("%s: %q does not contain %q", tt.name, b, tt.text)
+		}
+		if tt.bad != "" && strings.Contains(string(b), tt.bad) {
+			t.Errorf("%s: mojibake %q in %q", tt.name, tt.bad, b)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Classifier assign category to article
+type Classifier interface {
+	// Classify return category index in taxonomy or -1 and score of each category
+	Classify(a Article) (int, []float64)
+}
+
+// Centroid match article tf-idf with category centroids by cosine
+type Centroid struct {
+	tf        *TFIDF
+	categs    []Category
+	threshold float64 // min similarity with category
+}
+
+// NaiveBayes multinomial naive bayes classifier, scores are log-probabilities
+type NaiveBayes struct {
+	classes   map[string][]nbClass // by language
+	vocabSize map[string]int
+	stopWords map[string]interface{}
+	minProb   float64
+}
+
+type nbClass struct {
+	Idx      int            `json:"idx"`
+	LogPrior float64        `json:"log_prior"`
+	Counts   map[string]int `json:"counts"`
+	Total    int            `json:"total"`
+}
+
+// nbJSON naive bayes in model file
+type nbJSON struct {
+	Classes   map[string][]nbClass `json:"classes"`
+	VocabSize map[string]int       `json:"vocab_size"`
+	StopWords []string             `json:"stop_words"`
+}
+
+const (
+	defaultMinProb   = 0.5 // min probability of category for logreg and news classifier
+	defaultNBMinProb = 0   // naive bayes probabilities are not calibrated, most probable category is taken
+)
+
+// classifierName return --classifier option, exits on unknown classifier
+func classifierName() string {
+	name := optString("classifier", "centroid")
+	switch name {
+	case "centroid", "nb", "logreg":
+		return name
+	}
+	checkErr(fmt.Errorf("unknown classifier %s, expected centroid, nb or logreg", name))
+	return ""
+}
+
+// trainClassifier return classifier selected by --classifier option trained on labeled articles
+func trainClassifier(articles []Article) Classifier {
+	switch classifierName() {
+	case "nb":
+		return NewNaiveBayes(categsOf(articles))
+	case "logreg":
+		return NewLogReg(articles)
+	}
+	tf := NewTFIDF(WithStopWords())
+	return &Centroid{tf: tf, categs: centroids(tf, categsOf(articles)), threshold: categThreshold}
+}
+
+// Classify return category most similar to article above threshold, scores are cosine similarities
+func (c *Centroid) Classify(a Article) (int, []float64) {
+	w := c.tf.CalLang(a.LangCode, a.Words)
+	scores := make([]float64, len(taxonomy))
+	maxsim := float64(0)
+	maxj := -1
+	for j := range c.categs {
+		idx := taxonIndex(c.categs[j].ID)
+		if c.categs[j].LangCode != a.LangCode || idx < 0 {
+			continue
+		}
+		sim := Cosine(w, c.categs[j].Weights)
+		scores[idx] = sim
+		if sim > c.threshold && sim > maxsim {
+			maxsim = sim
+			maxj = idx
+		}
+	}
+	return maxj, scores
+}
+
+// NewNaiveBayes train classifier on category words, prior is share of category documents
+func NewNaiveBayes(categs []Category) *NaiveBayes {
+	nb := &NaiveBayes{
+		classes:   make(map[string][]nbClass),
+		vocabSize: make(map[string]int),
+		stopWords: stemmedStopWords(),
+		minProb:   optFloat("min-prob", defaultNBMinProb),
+	}
+	docs := make(map[string]int)
+	for _, c := range categs {
+		docs[c.LangCode] += c.Docs
+	}
+	vocabs := make(map[string]map[string]bool)
+	for _, c := range categs {
+		idx := taxonIndex(c.ID)
+		if c.Docs == 0 || idx < 0 {
+			continue
+		}
+		class := nbClass{
+			Idx:      idx,
+			LogPrior: math.Log(float64(c.Docs) / float64(docs[c.LangCode])),
+			Counts:   make(map[string]int),
+		}
+		if vocabs[c.LangCode] == nil {
+			vocabs[c.LangCode] = make(map[string]bool)
+		}
+		for _, term := range nb.tokens(c.LangCode, strings.Join(c.Words, " ")) {
+			class.Counts[term]++
+			class.Total++
+			vocabs[c.LangCode][term] = true
+		}
+		nb.classes[c.LangCode] = append(nb.classes[c.LangCode], class)
+	}
+	for lang, v := range vocabs {
+		nb.vocabSize[lang] = len(v)
+	}
+	return nb
+}
+
+// MarshalJSON write class word counts and stop words
+func (nb *NaiveBayes) MarshalJSON() ([]byte, error) {
+	j := nbJSON{Classes: nb.classes, VocabSize: nb.vocabSize}
+	for w := range nb.stopWords {
+		j.StopWords = append(j.StopWords, w)
+	}
+	sort.Strings(j.StopWords)
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON read class word counts and stop words
+func (nb *NaiveBayes) UnmarshalJSON(b []byte) error {
+	j := nbJSON{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	nb.classes, nb.vocabSize = j.Classes, j.VocabSize
+	nb.stopWords = make(map[string]interface{})
+	for _, w := range j.StopWords {
+		nb.stopWords[w] = nil
+	}
+	return nil
+}
+
+func (nb *NaiveBayes) tokens(lang, doc string) (res []string) {
+	for _, term := range tokenizerFor(lang).Seg(doc) {
+		if _, ok := nb.stopWords[term]; !ok {
+			res = append(res, term)
+		}
+	}
+	return
+}
+
+// LogProbs return normalized log-probability of each category for document,
+// -Inf for categories without train documents
+func (nb *NaiveBayes) LogProbs(lang, doc string) []float64 {
+	scores := make([]float64, len(taxonomy))
+	for i := range scores {
+		scores[i] = math.Inf(-1)
+	}
+	classes := nb.classes[lang]
+	if len(classes) == 0 {
+		return scores
+	}
+	tokens := nb.tokens(lang, doc)
+	v := float64(nb.vocabSize[lang])
+	for _, c := range classes {
+		score := c.LogPrior
+		for _, term := range tokens {
+			score += math.Log((float64(c.Counts[term]) + 1) / (float64(c.Total) + v))
+		}
+		scores[c.Idx] = score
+	}
+	// log-sum-exp normalization
+	max := math.Inf(-1)
+	for _, s := range scores {
+		max = math.Max(max, s)
+	}
+	sum := float64(0)
+	for _, s := range scores {
+		sum += math.Exp(s - max)
+	}
+	norm := max + math.Log(sum)
+	for i := range scores {
+		scores[i] -= norm
+	}
+	return scores
+}
+
+// Classify return most probable category, -1 if its probability is below --min-prob
+func (nb *NaiveBayes) Classify(a Article) (int, []float64) {
+	scores := nb.LogProbs(a.LangCode, a.Words)
+	best := -1
+	for i, s := range scores {
+		if math.IsInf(s, -1) {
+			continue
+		}
+		if best < 0 || s > scores[best] {
+			best = i
+		}
+	}
+	if best >= 0 && math.Exp(scores[best]) < nb.minProb {
+		best = -1
+	}
+	return best, scores
+}
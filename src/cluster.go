@@ -0,0 +1,180 @@
+package main
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Linkage similarity of clusters from similarities of their articles
+type Linkage int
+
+const (
+	// AverageLinkage mean similarity of all article pairs
+	AverageLinkage Linkage = iota
+	// CompleteLinkage similarity of least similar pair
+	CompleteLinkage
+	// SingleLinkage similarity of most similar pair
+	SingleLinkage
+)
+
+// parseLinkage return linkage by name, ok is false for unknown names
+func parseLinkage(name string) (l Linkage, ok bool) {
+	switch name {
+	case "average":
+		return AverageLinkage, true
+	case "complete":
+		return CompleteLinkage, true
+	case "single":
+		return SingleLinkage, true
+	}
+	return AverageLinkage, false
+}
+
+// clusterLink link of clusters a < b with linkage similarity at the time it is queued
+type clusterLink struct {
+	a, b int
+	sim  float64
+}
+
+// linkQueue max heap of cluster links, ties by smallest cluster indexes
+type linkQueue []clusterLink
+
+func (q linkQueue) Len() int { return len(q) }
+func (q linkQueue) Less(i, j int) bool {
+	if q[i].sim != q[j].sim {
+		return q[i].sim > q[j].sim
+	}
+	if q[i].a != q[j].a {
+		return q[i].a < q[j].a
+	}
+	return q[i].b < q[j].b
+}
+func (q linkQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *linkQueue) Push(x interface{}) { *q = append(*q, x.(clusterLink)) }
+func (q *linkQueue) Pop() interface{} {
+	old := *q
+	l := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return l
+}
+
+// Cluster agglomerative clustering of n items on similarity graph,
+// sims[i][j] is similarity of connected items i < j, missing edges are zero similarity.
+// Clusters with max linkage similarity are merged while their distance 1 - sim is below cutoff,
+// ties are broken by smallest item indexes. Clusters with items i, j where cannotLink(i, j)
+// are never merged, nil cannotLink links all items. Return clusters with more than one item.
+// Links are kept in priority queue, links changed by merge are queued again and outdated
+// ones are skipped, so merge costs links of merged clusters, not all links
+func Cluster(n int, sims map[int]map[int]float64, linkage Linkage, cutoff float64, cannotLink func(i, j int) bool) [][]int {
+	members := make([][]int, n)
+	links := make([]map[int]float64, n) // cluster links, sum of similarities for average linkage
+	for i := range members {
+		members[i] = []int{i}
+		links[i] = make(map[int]float64)
+	}
+	for i, row := range sims {
+		for j, s := range row {
+			links[i][j] = s
+			links[j][i] = s
+		}
+	}
+	canMerge := func(a, b int) bool {
+		if cannotLink == nil {
+			return true
+		}
+		for _, i := range members[a] {
+			for _, j := range members[b] {
+				if cannotLink(i, j) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	sim := func(a, b int) float64 {
+		s := links[a][b]
+		if linkage == AverageLinkage {
+			s /= float64(len(members[a]) * len(members[b]))
+		}
+		return s
+	}
+	queue := &linkQueue{}
+	push := func(a, b int) {
+		if a > b {
+			a, b = b, a
+		}
+		heap.Push(queue, clusterLink{a: a, b: b, sim: sim(a, b)})
+	}
+	for a := range links {
+		for b := range links[a] {
+			if a < b {
+				push(a, b)
+			}
+		}
+	}
+	for queue.Len() > 0 {
+		l := heap.Pop(queue).(clusterLink)
+		ba, bb := l.a, l.b
+		if members[ba] == nil || members[bb] == nil {
+			continue
+		}
+		if _, ok := links[ba][bb]; !ok || sim(ba, bb) != l.sim {
+			// link is changed by merge and queued again
+			continue
+		}
+		if 1-l.sim >= cutoff {
+			break
+		}
+		if !canMerge(ba, bb) {
+			// clusters only grow, so they are never merged
+			delete(links[ba], bb)
+			delete(links[bb], ba)
+			continue
+		}
+		// merge bb into ba, update links with other clusters
+		for c, s := range links[bb] {
+			if c == ba {
+				continue
+			}
+			delete(links[c], bb)
+			old, ok := links[ba][c]
+			switch linkage {
+			case AverageLinkage:
+				links[ba][c] = old + s
+			case SingleLinkage:
+				links[ba][c] = math.Max(old, s)
+			case CompleteLinkage:
+				if !ok {
+					continue
+				}
+				links[ba][c] = math.Min(old, s)
+			}
+			links[c][ba] = links[ba][c]
+		}
+		if linkage == CompleteLinkage {
+			// clusters not linked with both are not linked with merged cluster
+			for c := range links[ba] {
+				if _, ok := links[bb][c]; !ok && c != bb {
+					delete(links[ba], c)
+					delete(links[c], ba)
+				}
+			}
+		}
+		delete(links[ba], bb)
+		members[ba] = append(members[ba], members[bb]...)
+		members[bb] = nil
+		links[bb] = nil
+		for c := range links[ba] {
+			push(ba, c)
+		}
+	}
+	res := make([][]int, 0)
+	for _, m := range members {
+		if len(m) > 1 {
+			sort.Ints(m)
+			res = append(res, m)
+		}
+	}
+	return res
+}
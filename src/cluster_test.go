@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClusterCannotLink(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 0, 1, 2 are published a day and a half apart, 3 has no published time
+	articles := []Article{
+		{Published: start},
+		{Published: start.Add(36 * time.Hour)},
+		{Published: start.Add(72 * time.Hour)},
+		{},
+	}
+	cannotLink := func(i, j int) bool {
+		return tooFar(articles[i], articles[j], 2*day)
+	}
+	tests := []struct {
+		name string
+		sims map[int]map[int]float64
+		want [][]int
+	}{
+		{"chain", map[int]map[int]float64{0: {1: 0.9}, 1: {2: 0.8}}, [][]int{{0, 1}}},
+		{"untimed bridge", map[int]map[int]float64{0: {3: 0.9}, 2: {3: 0.8}}, [][]int{{0, 3}}},
+	}
+	for _, tt := range tests {
+		for _, linkage := range []Linkage{SingleLinkage, AverageLinkage} {
+			got := Cluster(len(articles), tt.sims, linkage, 0.5, cannotLink)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s linkage %d: got %v, want %v", tt.name, linkage, got, tt.want)
+			}
+		}
+		if got := Cluster(len(articles), tt.sims, SingleLinkage, 0.5, nil); len(got) != 1 || len(got[0]) != 3 {
+			t.Errorf("%s without cannot link: got %v, want one cluster of 3", tt.name, got)
+		}
+	}
+}
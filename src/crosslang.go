@@ -0,0 +1,230 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	crossToken = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+	ruTranslit = map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+		'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+		'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "c", 'ч': "ch", 'ш': "sh", 'щ': "sh",
+		'ъ': "", 'ы': "i", 'ь': "", 'э': "e", 'ю': "u", 'я': "a",
+	}
+	// latin spellings reduced to the same form as transliterated russian
+	latinNorm = strings.NewReplacer("kh", "h", "zh", "j", "ts", "c", "ch", "c", "sh", "s", "ph", "f",
+		"ck", "k", "q", "k", "x", "ks", "w", "v", "y", "i", "j", "i")
+
+	enMonths = "january february march april may june july august september october november december"
+	ruMonths = "января февраля марта апреля мая июня июля августа сентября октября ноября декабря"
+
+	// en-ru pairs of frequent news words, en word is the key of pair
+	crossDict = `president президент, minister министр, government правительство, election выборы,
+	parliament парламент, court суд, police полиция, army армия, war война, attack атака,
+	explosion взрыв, fire пожар, earthquake землетрясение, flood наводнение, crash крушение,
+	plane самолет, airport аэропорт, train поезд, ship судно, killed погибли, dead погибших,
+	injured пострадавшие, arrested задержан, sanctions санкции, oil нефть, gas газ, bank банк,
+	dollar доллар, ruble рубль, euro евро, market рынок, shares акции, price цена, inflation инфляция,
+	company компания, deal сделка, talks переговоры, summit саммит, protest протест, virus вирус,
+	vaccine вакцина, hospital больница, space космос, rocket ракета, satellite спутник,
+	football футбол, hockey хоккей, tennis теннис, match матч, championship чемпионат, cup кубок,
+	olympics олимпиада, film фильм, movie фильм, album альбом, concert концерт, award премия,
+	smartphone смартфон, iphone iphone, russia россия, ukraine украина, china китай, america америка,
+	germany германия, france франция, britain британия, turkey турция, syria сирия, iran иран,
+	moscow москва, london лондон, washington вашингтон, kiev киев, europe европа`
+	crossWords  map[string]string // stem of en or ru word -> key of pair
+	crossMonths map[string]int    // stem of en or ru month name -> month
+)
+
+func init() {
+	crossWords = make(map[string]string)
+	for _, pair := range strings.Split(crossDict, ",") {
+		words := strings.Fields(pair)
+		if len(words) != 2 {
+			continue
+		}
+		// synonyms with the same translation, like film and movie, share key
+		key := words[0]
+		if k, ok := crossWords[ruStem(words[1])]; ok {
+			key = k
+		}
+		crossWords[enStem(words[0])] = key
+		crossWords[ruStem(words[1])] = key
+	}
+	crossMonths = make(map[string]int)
+	for i, m := range strings.Fields(enMonths) {
+		crossMonths[enStem(m)] = i + 1
+	}
+	for i, m := range strings.Fields(ruMonths) {
+		crossMonths[ruStem(m)] = i + 1
+	}
+}
+
+// crossKeys return language independent keys of article: dictionary words,
+// transliterated names, numbers and dates. Capitalized stop words and words
+// capitalized only at sentence start are not names
+func crossKeys(a Article) map[string]bool {
+	keys := make(map[string]bool)
+	text := a.Title + ". " + a.Desc
+	if r := []rune(a.Text); len(r) > 1000 {
+		text += ". " + string(r[:1000])
+	} else {
+		text += ". " + a.Text
+	}
+	locs := crossToken.FindAllStringIndex(text, -1)
+	tokens := make([]string, len(locs))
+	initial := make([]bool, len(locs))
+	midCaps := make(map[string]bool) // capitalized tokens inside sentences
+	for i, loc := range locs {
+		tokens[i] = text[loc[0]:loc[1]]
+		initial[i] = sentenceStart(text[:loc[0]])
+		if !initial[i] && unicode.IsUpper([]rune(tokens[i])[0]) {
+			midCaps[tokens[i]] = true
+		}
+	}
+	for i, t := range tokens {
+		lower := strings.ToLower(t)
+		stem := enStem(lower)
+		if isCyrillic(lower) {
+			stem = ruStem(lower)
+		}
+		if key, ok := crossWords[stem]; ok {
+			keys["w:"+key] = true
+			continue
+		}
+		first := []rune(t)[0]
+		// english month names are capitalized, may and march are also verbs
+		if month, ok := crossMonths[stem]; ok && (unicode.IsUpper(first) || isCyrillic(lower)) {
+			keys["m:"+strconv.Itoa(month)] = true
+			if i > 0 && len(tokens[i-1]) <= 2 && unicode.IsDigit([]rune(tokens[i-1])[0]) {
+				keys["d:"+strings.TrimLeft(tokens[i-1], "0")+"."+strconv.Itoa(month)] = true
+			}
+			continue
+		}
+		switch {
+		case unicode.IsDigit(first):
+			if len(t) >= 2 {
+				keys["n:"+strings.TrimLeft(t, "0")] = true
+			}
+		case unicode.IsUpper(first) && len([]rune(t)) > 2:
+			if isCrossStopWord(lower) || (initial[i] && !midCaps[t]) {
+				continue
+			}
+			if name := translitName(stem); len(name) > 2 {
+				keys["e:"+name] = true
+			}
+		}
+	}
+	return keys
+}
+
+// sentenceStart return true if token after text starts sentence
+func sentenceStart(text string) bool {
+	text = strings.TrimRight(text, " \t\r\n\"'«“„—–-(")
+	return text == "" || strings.ContainsAny(text[len(text)-1:], ".!?") || strings.HasSuffix(text, "…")
+}
+
+// isCrossStopWord return true if lowercase word is english or russian stop word
+func isCrossStopWord(lower string) bool {
+	lang := "en"
+	if isCyrillic(lower) {
+		lang = "ru"
+	}
+	_, ok := stopWordsFor(lang)[lower]
+	return ok
+}
+
+func isCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// translitName return consonant skeleton of transliterated lowercase name,
+// so Путин and Putin or Трамп and Trump have the same form
+func translitName(name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		if s, ok := ruTranslit[r]; ok {
+			sb.WriteString(s)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	s := latinNorm.Replace(sb.String())
+	res := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if i > 0 && strings.IndexByte("aeiou", c) >= 0 {
+			continue
+		}
+		if len(res) > 0 && res[len(res)-1] == c {
+			continue
+		}
+		res = append(res, c)
+	}
+	return string(res)
+}
+
+// relatedThreads return ids of threads in other language about the same event
+// by first article file of thread, threads are linked by shared language independent keys.
+// Thread id is name of its first article, title may change as thread grows
+func relatedThreads(allpairs [][]Article) map[string][]string {
+	related := make(map[string][]string)
+	if !optBool("cross-lang", false) {
+		return related
+	}
+	minSim := optFloat("cross-sim", 0.3)
+	minShared := optInt("cross-shared", 3)
+	keys := make([]map[string]bool, len(allpairs))
+	for i, p := range allpairs {
+		keys[i] = make(map[string]bool)
+		for _, a := range p {
+			for k := range crossKeys(a) {
+				keys[i][k] = true
+			}
+		}
+	}
+	for i, p := range allpairs {
+		if len(p) == 0 {
+			continue
+		}
+		type link struct {
+			id  string
+			sim float64
+		}
+		links := make([]link, 0)
+		for j, q := range allpairs {
+			if len(q) == 0 || q[0].LangCode == p[0].LangCode {
+				continue
+			}
+			shared := 0
+			for k := range keys[i] {
+				if keys[j][k] {
+					shared++
+				}
+			}
+			sim := float64(shared) / math.Sqrt(float64(len(keys[i])*len(keys[j]))+1e-9)
+			if shared >= minShared && sim >= minSim {
+				links = append(links, link{id: q[0].Name, sim: sim})
+			}
+		}
+		sort.SliceStable(links, func(a, b int) bool {
+			return links[a].sim > links[b].sim
+		})
+		for _, l := range links {
+			related[p[0].File] = append(related[p[0].File], l.id)
+		}
+	}
+	return related
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestCrossKeys(t *testing.T) {
+	keys := crossKeys(Article{
+		Title: "Reportedly, Putin Met Trump According To Aides",
+		Desc:  "However the talks were short. Officials said Putin will visit again",
+		Text:  "Трамп и Путин провели переговоры. Встреча прошла в Хельсинки",
+	})
+	for _, k := range []string{"e:ptn", "e:trmp", "e:hlsnk", "w:talks"} {
+		if !keys[k] {
+			t.Errorf("missing key %s in %v", k, keys)
+		}
+	}
+	// stop words and words capitalized only at sentence start are not names
+	for _, k := range []string{"e:rprt", "e:acrd", "e:hv", "e:ofc", "e:vstrc"} {
+		if keys[k] {
+			t.Errorf("unexpected key %s in %v", k, keys)
+		}
+	}
+}
+
+func TestCrossKeysSynonyms(t *testing.T) {
+	for _, text := range []string{"film", "movie", "фильм"} {
+		if keys := crossKeys(Article{Text: text}); !keys["w:film"] {
+			t.Errorf("%s: missing key w:film in %v", text, keys)
+		}
+	}
+}
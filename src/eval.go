@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Eval cross-validation result of classifier
+type Eval struct {
+	Classifier string      `json:"classifier"`
+	Folds      int         `json:"folds"`
+	Docs       int         `json:"docs"`
+	Categories []EvalScore `json:"categories"`
+	Macro      EvalScore   `json:"macro"`
+	Micro      EvalScore   `json:"micro"`
+	// Confusion rows are true categories, columns are predicted categories,
+	// last column counts unclassified articles
+	Confusion [][]int `json:"confusion"`
+}
+
+// EvalScore precision, recall and f1 of category or average
+type EvalScore struct {
+	Category  string  `json:"category,omitempty"`
+	Support   int     `json:"support"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// evaluate run k-fold cross-validation of --classifier on dir/<lang>/<id> folders,
+// print text report or json with --format=json
+func evaluate(dir string) {
+	e := CrossValidate(trainArticles(dir), optInt("folds", 5))
+	if optString("format", "text") == "json" {
+		b, err := json.MarshalIndent(e, "", "  ")
+		checkErr(err)
+		fmt.Println(string(b))
+		return
+	}
+	fmt.Print(e)
+}
+
+// CrossValidate train classifier on k-1 folds and classify articles of remaining fold,
+// folds are stratified by language and category
+func CrossValidate(articles []Article, k int) *Eval {
+	if k < 2 {
+		k = 2
+	}
+	n := len(taxonomy)
+	e := &Eval{
+		Classifier: classifierName(),
+		Folds:      k,
+		Docs:       len(articles),
+		Confusion:  make([][]int, n),
+	}
+	for i := range e.Confusion {
+		e.Confusion[i] = make([]int, n+1)
+	}
+	folds := make([]int, len(articles))
+	seen := make(map[string]int)
+	for i, a := range articles {
+		key := fmt.Sprintf("%s/%d", a.LangCode, a.CategoryId)
+		folds[i] = seen[key] % k
+		seen[key]++
+	}
+	for fold := 0; fold < k; fold++ {
+		var trainSet, testSet []Article
+		for i, a := range articles {
+			if folds[i] == fold {
+				testSet = append(testSet, a)
+			} else {
+				trainSet = append(trainSet, a)
+			}
+		}
+		if len(testSet) == 0 {
+			continue
+		}
+		clf := trainClassifier(trainSet)
+		for _, a := range testSet {
+			pred, _ := clf.Classify(a)
+			if pred < 0 {
+				pred = n
+			}
+			e.Confusion[a.CategoryId][pred]++
+		}
+	}
+	e.score()
+	return e
+}
+
+// score calculate per category and averaged scores from confusion matrix,
+// macro average is over categories with support or predictions
+func (e *Eval) score() {
+	var tps, fps, fns, used int
+	n := len(taxonomy)
+	e.Categories = make([]EvalScore, n)
+	for c := 0; c < n; c++ {
+		tp, fp, fn := e.Confusion[c][c], 0, 0
+		for j := 0; j <= n; j++ {
+			if j != c {
+				fn += e.Confusion[c][j]
+			}
+		}
+		for i := 0; i < n; i++ {
+			if i != c {
+				fp += e.Confusion[i][c]
+			}
+		}
+		s := prf(tp, fp, fn)
+		s.Category = categName(c)
+		e.Categories[c] = s
+		tps, fps, fns = tps+tp, fps+fp, fns+fn
+
+		if tp+fp+fn == 0 {
+			continue
+		}
+		used++
+		e.Macro.Support += s.Support
+		e.Macro.Precision += s.Precision
+		e.Macro.Recall += s.Recall
+		e.Macro.F1 += s.F1
+	}
+	if used > 0 {
+		e.Macro.Precision /= float64(used)
+		e.Macro.Recall /= float64(used)
+		e.Macro.F1 /= float64(used)
+	}
+	e.Micro = prf(tps, fps, fns)
+}
+
+// prf return precision, recall and f1 for true positives, false positives and false negatives
+func prf(tp, fp, fn int) (s EvalScore) {
+	s.Support = tp + fn
+	if tp+fp > 0 {
+		s.Precision = float64(tp) / float64(tp+fp)
+	}
+	if tp+fn > 0 {
+		s.Recall = float64(tp) / float64(tp+fn)
+	}
+	if s.Precision+s.Recall > 0 {
+		s.F1 = 2 * s.Precision * s.Recall / (s.Precision + s.Recall)
+	}
+	return
+}
+
+// String return text report with scores and confusion matrix
+func (e *Eval) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "classifier: %s, folds: %d, docs: %d\n\n", e.Classifier, e.Folds, e.Docs)
+	fmt.Fprintf(&sb, "%-14s %9s %9s %9s %9s\n", "category", "precision", "recall", "f1", "support")
+	line := func(name string, s EvalScore) {
+		fmt.Fprintf(&sb, "%-14s %9.3f %9.3f %9.3f %9d\n", name, s.Precision, s.Recall, s.F1, s.Support)
+	}
+	for _, s := range e.Categories {
+		line(s.Category, s)
+	}
+	sb.WriteString("\n")
+	line("macro avg", e.Macro)
+	line("micro avg", e.Micro)
+
+	sb.WriteString("\nconfusion (rows true, columns predicted):\n")
+	fmt.Fprintf(&sb, "%-14s", "")
+	for c := range taxonomy {
+		fmt.Fprintf(&sb, " %6.6s", categName(c))
+	}
+	fmt.Fprintf(&sb, " %6s\n", "none")
+	for c, row := range e.Confusion {
+		fmt.Fprintf(&sb, "%-14s", categName(c))
+		for _, n := range row {
+			fmt.Fprintf(&sb, " %6d", n)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestEvalMacroSkipsEmptyCategories(t *testing.T) {
+	n := len(taxonomy)
+	e := &Eval{Confusion: make([][]int, n)}
+	for i := range e.Confusion {
+		e.Confusion[i] = make([]int, n+1)
+	}
+	// two categories classified perfectly, others have no documents and no predictions
+	e.Confusion[1][1] = 3
+	e.Confusion[3][3] = 2
+	e.score()
+	if e.Macro.F1 != 1 || e.Macro.Precision != 1 || e.Macro.Recall != 1 {
+		t.Errorf("macro = %+v, expected 1", e.Macro)
+	}
+	if e.Macro.Support != 5 {
+		t.Errorf("macro support = %d, expected 5", e.Macro.Support)
+	}
+}
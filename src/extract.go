@@ -0,0 +1,166 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const (
+	boilerplate = "script, style, noscript, iframe, svg, button, select, nav, footer, aside"
+	blocks      = "p, h1, h2, h3, h4, h5, h6, li, blockquote, pre, figcaption"
+)
+
+var (
+	unlikely = regexp.MustCompile(`(?i)comment|footer|nav|menu|sidebar|share|social|related|read-?also|readmore|subscri|advert|promo|banner|breadcrumb|tags|widget|popup|cookie`)
+	likely   = regexp.MustCompile(`(?i)article|body|content|entry|main|post|text|story`)
+	space    = regexp.MustCompile(`\s+`)
+)
+
+// extractText return main content of document, paragraphs separated by new line
+// Blocks are scored by text and link density like readability does
+func extractText(doc *goquery.Document) string {
+	doc.Find(boilerplate).Remove()
+	// some sites wrap whole page in form, only link lists in forms are removed
+	doc.Find("form").Each(func(i int, s *goquery.Selection) {
+		if linkDensity(s) >= 0.5 {
+			s.Remove()
+		}
+	})
+	doc.Find("*").Each(func(i int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "html", "body", "article", "main":
+			return
+		}
+		attrs := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if unlikely.MatchString(attrs) && !likely.MatchString(attrs) {
+			s.Remove()
+		}
+	})
+
+	scores := make(map[*html.Node]float64)
+	candidates := make([]*goquery.Selection, 0)
+	add := func(s *goquery.Selection, score float64) {
+		n := s.Get(0)
+		if _, ok := scores[n]; !ok {
+			scores[n] = classWeight(s)
+			candidates = append(candidates, s)
+		}
+		scores[n] += score
+	}
+	doc.Find("p, pre, td, blockquote").Each(func(i int, s *goquery.Selection) {
+		text := clean(s.Text())
+		l := len([]rune(text))
+		if l < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(l)/100, 3)
+		parent := s.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		add(parent, score)
+		if grand := parent.Parent(); grand.Length() > 0 {
+			add(grand, score/2)
+		}
+	})
+
+	var best *goquery.Selection
+	bestScore := float64(0)
+	for _, c := range candidates {
+		score := scores[c.Get(0)] * (1 - linkDensity(c))
+		scores[c.Get(0)] = score
+		if best == nil || score > bestScore {
+			best = c
+			bestScore = score
+		}
+	}
+	if best == nil {
+		return strings.Join(paragraphs(doc.Find("body")), "\n")
+	}
+
+	res := make([]string, 0)
+	threshold := math.Max(10, bestScore*0.2)
+	best.Parent().Children().Each(func(i int, s *goquery.Selection) {
+		n := s.Get(0)
+		include := n == best.Get(0)
+		if score, ok := scores[n]; ok && score >= threshold {
+			include = true
+		}
+		if goquery.NodeName(s) == "p" {
+			l := len([]rune(clean(s.Text())))
+			ld := linkDensity(s)
+			if (l > 80 && ld < 0.25) || (l > 0 && ld == 0 && strings.HasSuffix(clean(s.Text()), ".")) {
+				include = true
+			}
+		}
+		if include {
+			res = append(res, paragraphs(s)...)
+		}
+	})
+	return strings.Join(res, "\n")
+}
+
+// paragraphs return cleaned text of block elements without link lists
+func paragraphs(s *goquery.Selection) (res []string) {
+	found := s.Find(blocks)
+	if found.Length() == 0 {
+		if text := clean(s.Text()); text != "" {
+			res = append(res, text)
+		}
+		return
+	}
+	found.Each(func(i int, b *goquery.Selection) {
+		if b.Find(blocks).Length() > 0 {
+			return
+		}
+		text := clean(b.Text())
+		if text == "" || linkDensity(b) >= 0.5 {
+			return
+		}
+		res = append(res, text)
+	})
+	return
+}
+
+// linkDensity return share of link text in selection text
+func linkDensity(s *goquery.Selection) float64 {
+	l := len([]rune(clean(s.Text())))
+	if l == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(i int, a *goquery.Selection) {
+		links += len([]rune(clean(a.Text())))
+	})
+	return float64(links) / float64(l)
+}
+
+func classWeight(s *goquery.Selection) (w float64) {
+	for _, attr := range []string{"class", "id"} {
+		v := s.AttrOr(attr, "")
+		if v == "" {
+			continue
+		}
+		if likely.MatchString(v) {
+			w += 25
+		}
+		if unlikely.MatchString(v) {
+			w -= 25
+		}
+	}
+	switch goquery.NodeName(s) {
+	case "article", "main":
+		w += 10
+	case "div":
+		w += 5
+	}
+	return
+}
+
+func clean(text string) string {
+	return space.ReplaceAllString(strings.TrimSpace(text), " ")
+}
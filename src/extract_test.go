@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractTextForm(t *testing.T) {
+	page := `<html><body><form id="aspnetForm">
+<div class="content"><p>The regional government approved the new budget on Monday, increasing spending on schools and roads.</p>
+<p>Deputies said the changes will take effect next year, after the final reading in parliament.</p></div>
+</form>
+<form class="links"><div><a href="/1">Politics</a> <a href="/2">Economy</a> <a href="/3">Sports</a></div></form>
+</body></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := extractText(doc)
+	if !strings.Contains(text, "approved the new budget") || !strings.Contains(text, "final reading") {
+		t.Errorf("text of page in form is lost: %q", text)
+	}
+	if strings.Contains(text, "Politics") {
+		t.Errorf("link list form is not removed: %q", text)
+	}
+}
@@ -5,4 +5,5 @@ go 1.13
 require (
 	github.com/PuerkitoBio/goquery v1.5.0
 	github.com/abadojack/whatlanggo v1.0.1
+	golang.org/x/net v0.0.0-20181114220301-adae6a3d119a
 )
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const authorityFile = "authority.txt"
+
+// builtinAuthority authority of well known sources, other sources have defaultAuthority
+var builtinAuthority = map[string]float64{
+	"reuters.com": 1, "apnews.com": 1, "bbc.com": 1, "nytimes.com": 1, "theguardian.com": 1,
+	"washingtonpost.com": 1, "bloomberg.com": 1, "cnn.com": 0.9, "wsj.com": 1, "ft.com": 1,
+	"tass.ru": 1, "ria.ru": 1, "interfax.ru": 1, "rbc.ru": 0.9, "kommersant.ru": 0.9,
+	"vedomosti.ru": 0.9, "lenta.ru": 0.8, "meduza.io": 0.8, "rt.com": 0.7,
+}
+
+const defaultAuthority = 0.5
+
+// Importance weights of thread signals, set by --w-<signal> options
+type Importance struct {
+	Size      float64 // log of thread size
+	Domains   float64 // log of distinct domains
+	Authority float64 // mean authority of thread domains
+	Recency   float64 // exponential decay of thread age by --half-life
+	Burst     float64 // log of max articles published in --burst-window
+	HalfLife  time.Duration
+	Window    time.Duration
+	authority map[string]float64
+}
+
+// NewImportance return importance with weights from options
+// and source authority from authority.txt lines "domain weight"
+func NewImportance() *Importance {
+	imp := &Importance{
+		Size:      optFloat("w-size", 1),
+		Domains:   optFloat("w-domains", 1),
+		Authority: optFloat("w-authority", 1),
+		Recency:   optFloat("w-recency", 1),
+		Burst:     optFloat("w-burst", 1),
+		HalfLife:  time.Duration(optInt("half-life", 24*3600)) * time.Second,
+		Window:    time.Duration(optInt("burst-window", 3600)) * time.Second,
+		authority: make(map[string]float64),
+	}
+	for d, w := range builtinAuthority {
+		imp.authority[d] = w
+	}
+	f, err := os.Open(optString("authority", authorityFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			checkErr(err)
+		}
+		return imp
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if w, err := strconv.ParseFloat(fields[1], 64); err == nil {
+			imp.authority[strings.ToLower(fields[0])] = w
+		}
+	}
+	checkErr(scanner.Err())
+	return imp
+}
+
+// Score return weighted sum of thread signals, recency is relative to latest time
+func (imp *Importance) Score(thread []Article, latest time.Time) float64 {
+	domains := make(map[string]bool)
+	authority := float64(0)
+	times := make([]time.Time, 0, len(thread))
+	for _, a := range thread {
+		if !domains[a.Domain] {
+			domains[a.Domain] = true
+			w, ok := imp.authority[a.Domain]
+			if !ok {
+				w = defaultAuthority
+			}
+			authority += w
+		}
+		if !a.Published.IsZero() {
+			times = append(times, a.Published)
+		}
+	}
+	score := imp.Size*math.Log(1+float64(len(thread))) +
+		imp.Domains*math.Log(1+float64(len(domains)))
+	if len(domains) > 0 {
+		score += imp.Authority * authority / float64(len(domains))
+	}
+	if len(times) == 0 {
+		return score
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	if imp.HalfLife > 0 {
+		age := latest.Sub(times[len(times)-1])
+		if age < 0 {
+			age = 0
+		}
+		score += imp.Recency * math.Pow(0.5, float64(age)/float64(imp.HalfLife))
+	}
+	burst, start := 0, 0
+	for end := range times {
+		for times[end].Sub(times[start]) > imp.Window {
+			start++
+		}
+		if end-start+1 > burst {
+			burst = end - start + 1
+		}
+	}
+	return score + imp.Burst*math.Log(1+float64(burst))
+}
+
+// latestTime return latest published time of articles in threads
+func latestTime(allpairs [][]Article) (latest time.Time) {
+	for _, p := range allpairs {
+		for _, a := range p {
+			if a.Published.After(latest) {
+				latest = a.Published
+			}
+		}
+	}
+	return
+}
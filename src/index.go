@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// TermIndex inverted index from top tf-idf terms to articles
+type TermIndex struct {
+	postings map[int32][]int
+	terms    [][]int32 // top terms of each article
+}
+
+// NewTermIndex index first topTerms terms of articles About
+func NewTermIndex(articles []Article, topTerms int) *TermIndex {
+	ti := &TermIndex{
+		postings: make(map[int32][]int),
+		terms:    make([][]int32, len(articles)),
+	}
+	for i, a := range articles {
+		fields := strings.Fields(a.About)
+		if len(fields) > topTerms {
+			fields = fields[:topTerms]
+		}
+		for _, f := range fields {
+			id := vocab.ID(f)
+			ti.terms[i] = append(ti.terms[i], id)
+			ti.postings[id] = append(ti.postings[id], i)
+		}
+	}
+	return ti
+}
+
+// Candidates return sorted articles sharing at least minShared top terms with article i,
+// all other articles if minShared is not positive
+func (ti *TermIndex) Candidates(i, minShared int) []int {
+	if minShared <= 0 {
+		res := make([]int, 0, len(ti.terms))
+		for j := range ti.terms {
+			if j != i {
+				res = append(res, j)
+			}
+		}
+		return res
+	}
+	shared := make(map[int]int)
+	for _, id := range ti.terms[i] {
+		for _, j := range ti.postings[id] {
+			if j != i {
+				shared[j]++
+			}
+		}
+	}
+	res := make([]int, 0, len(shared))
+	for j, cnt := range shared {
+		if cnt >= minShared {
+			res = append(res, j)
+		}
+	}
+	sort.Ints(res)
+	return res
+}
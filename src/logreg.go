@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// LogReg softmax logistic regression on tf-idf vectors trained with SGD,
+// scores are probabilities calibrated with temperature on held out documents
+type LogReg struct {
+	tf      *TFIDF
+	models  map[string]*lrModel // by language
+	minProb float64
+}
+
+type lrModel struct {
+	classes []int       // category indexes
+	weights [][]float64 // class x term id
+	bias    []float64
+	dim     int
+	temp    float64
+}
+
+// lrJSON logistic regression in model file
+type lrJSON struct {
+	Corpus Corpus              `json:"corpus"`
+	Models map[string]*lrModel `json:"models"`
+}
+
+// lrModelJSON weights are stored by term, as term ids differ between runs
+type lrModelJSON struct {
+	Classes []int                `json:"classes"`
+	Weights []map[string]float64 `json:"weights"`
+	Bias    []float64            `json:"bias"`
+	Temp    float64              `json:"temp"`
+}
+
+type lrSample struct {
+	x     Vector
+	class int // position in classes
+}
+
+// NewLogReg train model on labeled articles
+func NewLogReg(articles []Article) *LogReg {
+	lr := &LogReg{
+		tf:      NewTFIDF(WithStopWords()),
+		models:  make(map[string]*lrModel),
+		minProb: optFloat("min-prob", defaultMinProb),
+	}
+	for _, a := range articles {
+		lr.tf.AddLangDocs(a.LangCode, a.Words)
+	}
+	byLang := make(map[string][]Article)
+	for _, a := range articles {
+		byLang[a.LangCode] = append(byLang[a.LangCode], a)
+	}
+	for lang, docs := range byLang {
+		lr.models[lang] = lr.train(docs)
+	}
+	return lr
+}
+
+// MarshalJSON write document frequencies and models
+func (lr *LogReg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(lrJSON{Corpus: corpusOf(lr.tf), Models: lr.models})
+}
+
+// UnmarshalJSON read document frequencies and models
+func (lr *LogReg) UnmarshalJSON(b []byte) error {
+	j := lrJSON{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	lr.tf, lr.models = j.Corpus.TFIDF(), j.Models
+	return nil
+}
+
+// features return l2 normalized tf-idf vector of article
+func (lr *LogReg) features(a Article) Vector {
+	v := lr.tf.CalLang(a.LangCode, a.Words)
+	if v.Norm == 0 {
+		return v
+	}
+	x := Vector{IDs: v.IDs, Weights: make([]float64, len(v.Weights)), Norm: 1}
+	for i, w := range v.Weights {
+		x.Weights[i] = w / v.Norm
+	}
+	return x
+}
+
+func (lr *LogReg) train(docs []Article) *lrModel {
+	seen := make(map[int]bool)
+	classes := make([]int, 0)
+	for _, a := range docs {
+		if !seen[a.CategoryId] {
+			seen[a.CategoryId] = true
+			classes = append(classes, a.CategoryId)
+		}
+	}
+	sort.Ints(classes)
+	pos := make(map[int]int)
+	for i, c := range classes {
+		pos[c] = i
+	}
+	samples := make([]lrSample, len(docs))
+	for i, a := range docs {
+		samples[i] = lrSample{x: lr.features(a), class: pos[a.CategoryId]}
+	}
+	return fitCalibrated(samples, classes)
+}
+
+// fitCalibrated train model on samples with temperature fitted on held out samples
+func fitCalibrated(samples []lrSample, classes []int) *lrModel {
+	rng := rand.New(rand.NewSource(1))
+	temp := float64(1)
+	if len(samples) >= 20 {
+		// hold out 20% to fit temperature, then train on all documents
+		perm := rng.Perm(len(samples))
+		cut := len(samples) * 4 / 5
+		trainSet := make([]lrSample, 0, cut)
+		holdout := make([]lrSample, 0, len(samples)-cut)
+		for i, p := range perm {
+			if i < cut {
+				trainSet = append(trainSet, samples[p])
+			} else {
+				holdout = append(holdout, samples[p])
+			}
+		}
+		temp = fitLR(trainSet, classes, rng).calibrate(holdout)
+	}
+	m := fitLR(samples, classes, rng)
+	m.temp = temp
+	return m
+}
+
+// fitLR train softmax regression with SGD and l2 regularization
+func fitLR(samples []lrSample, classes []int, rng *rand.Rand) *lrModel {
+	m := &lrModel{
+		classes: classes,
+		weights: make([][]float64, len(classes)),
+		bias:    make([]float64, len(classes)),
+		dim:     vocab.Len(),
+		temp:    1,
+	}
+	for k := range m.weights {
+		m.weights[k] = make([]float64, m.dim)
+	}
+	epochs := optInt("epochs", 10)
+	rate := optFloat("lr", 0.5)
+	l2 := optFloat("l2", 1e-4)
+	for e := 0; e < epochs; e++ {
+		eta := rate / float64(1+e)
+		for _, i := range rng.Perm(len(samples)) {
+			s := samples[i]
+			p := m.probs(s.x, 1)
+			for k := range p {
+				g := p[k]
+				if k == s.class {
+					g--
+				}
+				m.bias[k] -= eta * g
+				w := m.weights[k]
+				for j, id := range s.x.IDs {
+					if int(id) >= m.dim {
+						continue
+					}
+					w[id] -= eta * (g*s.x.Weights[j] + l2*w[id])
+				}
+			}
+		}
+	}
+	return m
+}
+
+// MarshalJSON write non zero weights by term
+func (m *lrModel) MarshalJSON() ([]byte, error) {
+	j := lrModelJSON{Classes: m.classes, Weights: make([]map[string]float64, len(m.weights)), Bias: m.bias, Temp: m.temp}
+	for k, w := range m.weights {
+		j.Weights[k] = make(map[string]float64)
+		for id, x := range w {
+			if x != 0 {
+				j.Weights[k][vocab.Term(int32(id))] = x
+			}
+		}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON read weights by term, terms are added to vocab
+func (m *lrModel) UnmarshalJSON(b []byte) error {
+	j := lrModelJSON{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	for _, w := range j.Weights {
+		for term := range w {
+			vocab.ID(term)
+		}
+	}
+	*m = lrModel{classes: j.Classes, weights: make([][]float64, len(j.Weights)), bias: j.Bias, dim: vocab.Len(), temp: j.Temp}
+	for k, w := range j.Weights {
+		m.weights[k] = make([]float64, m.dim)
+		for term, x := range w {
+			m.weights[k][vocab.ID(term)] = x
+		}
+	}
+	return nil
+}
+
+// probs return softmax probabilities of classes, logits are divided by temperature
+func (m *lrModel) probs(x Vector, temp float64) []float64 {
+	p := make([]float64, len(m.classes))
+	max := math.Inf(-1)
+	for k := range p {
+		z := m.bias[k]
+		w := m.weights[k]
+		for j, id := range x.IDs {
+			if int(id) < m.dim {
+				z += w[id] * x.Weights[j]
+			}
+		}
+		p[k] = z / temp
+		max = math.Max(max, p[k])
+	}
+	sum := float64(0)
+	for k := range p {
+		p[k] = math.Exp(p[k] - max)
+		sum += p[k]
+	}
+	for k := range p {
+		p[k] /= sum
+	}
+	return p
+}
+
+// calibrate return temperature with min negative log-likelihood on samples
+func (m *lrModel) calibrate(samples []lrSample) float64 {
+	best, bestLoss := float64(1), math.Inf(1)
+	for t := 0.1; t <= 10; t *= 1.1 {
+		loss := float64(0)
+		for _, s := range samples {
+			loss -= math.Log(math.Max(m.probs(s.x, t)[s.class], 1e-12))
+		}
+		if loss < bestLoss {
+			best, bestLoss = t, loss
+		}
+	}
+	return best
+}
+
+// Classify return most probable category, -1 if its probability is below --min-prob
+func (lr *LogReg) Classify(a Article) (int, []float64) {
+	scores := make([]float64, len(taxonomy))
+	m := lr.models[a.LangCode]
+	if m == nil {
+		return -1, scores
+	}
+	p := m.probs(lr.features(a), m.temp)
+	best := -1
+	for k, c := range m.classes {
+		scores[c] = p[k]
+		if best < 0 || p[k] > scores[best] {
+			best = c
+		}
+	}
+	if best >= 0 && scores[best] < lr.minProb {
+		best = -1
+	}
+	return best, scores
+}
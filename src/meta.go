@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Meta parsed html meta fields and main text
+type Meta struct {
+	Title     string
+	Desc      string
+	URL       string
+	SName     string
+	Text      string
+	Published time.Time
+	Modified  time.Time
+	Authors   []string
+	Image     string
+	Section   string
+	Tags      []string
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02",
+}
+
+// parseMeta read og, article and JSON-LD meta, then <time> elements
+func parseMeta(doc *goquery.Document) (m Meta) {
+	doc.Find("meta").Each(func(i int, s *goquery.Selection) {
+		op, _ := s.Attr("property")
+		if op == "" {
+			op, _ = s.Attr("name")
+		}
+		if op == "" {
+			op, _ = s.Attr("itemprop")
+		}
+		con, _ := s.Attr("content")
+		con = strings.TrimSpace(con)
+		if con == "" {
+			return
+		}
+		switch op {
+		case "og:title":
+			m.Title = con
+		case "og:description":
+			m.Desc = con
+		case "og:url":
+			m.URL = con
+		case "og:site_name":
+			m.SName = strings.ToLower(con)
+		case "og:image":
+			if m.Image == "" {
+				m.Image = con
+			}
+		case "article:published_time", "datePublished", "pubdate":
+			if m.Published.IsZero() {
+				m.Published = parseTime(con)
+			}
+		case "article:modified_time", "og:updated_time", "dateModified":
+			if m.Modified.IsZero() {
+				m.Modified = parseTime(con)
+			}
+		case "article:author", "author":
+			m.Authors = appendUniq(m.Authors, con)
+		case "article:section":
+			if m.Section == "" {
+				m.Section = con
+			}
+		case "article:tag":
+			m.Tags = appendUniq(m.Tags, con)
+		}
+	})
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(i int, s *goquery.Selection) {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &v); err != nil {
+			return
+		}
+		for _, ld := range ldArticles(v) {
+			m.fillLD(ld)
+		}
+	})
+
+	if m.Published.IsZero() {
+		doc.Find("time").EachWithBreak(func(i int, s *goquery.Selection) bool {
+			t := parseTime(s.AttrOr("datetime", ""))
+			if t.IsZero() {
+				return true
+			}
+			m.Published = t
+			return false
+		})
+	}
+	return
+}
+
+// fillLD fill empty fields from JSON-LD article object
+func (m *Meta) fillLD(ld map[string]interface{}) {
+	if m.Published.IsZero() {
+		m.Published = parseTime(ldString(ld["datePublished"]))
+	}
+	if m.Modified.IsZero() {
+		m.Modified = parseTime(ldString(ld["dateModified"]))
+	}
+	if m.Title == "" {
+		m.Title = ldString(ld["headline"])
+	}
+	if m.Image == "" {
+		m.Image = ldString(ld["image"])
+	}
+	if m.Section == "" {
+		m.Section = ldString(ld["articleSection"])
+	}
+	if len(m.Authors) == 0 {
+		for _, a := range ldStrings(ld["author"]) {
+			m.Authors = appendUniq(m.Authors, a)
+		}
+	}
+	if len(m.Tags) == 0 {
+		for _, k := range ldStrings(ld["keywords"]) {
+			for _, t := range strings.Split(k, ",") {
+				m.Tags = appendUniq(m.Tags, strings.TrimSpace(t))
+			}
+		}
+	}
+}
+
+// ldArticles return article objects from JSON-LD value, including @graph
+func ldArticles(v interface{}) (res []map[string]interface{}) {
+	switch t := v.(type) {
+	case []interface{}:
+		for _, it := range t {
+			res = append(res, ldArticles(it)...)
+		}
+	case map[string]interface{}:
+		if g, ok := t["@graph"]; ok {
+			res = append(res, ldArticles(g)...)
+		}
+		for _, typ := range ldStrings(t["@type"]) {
+			switch typ {
+			case "NewsArticle", "Article", "ReportageNewsArticle", "AnalysisNewsArticle", "BlogPosting":
+				return append(res, t)
+			}
+		}
+	}
+	return
+}
+
+// ldString return first string value, object name or url
+func ldString(v interface{}) string {
+	if s := ldStrings(v); len(s) > 0 {
+		return s[0]
+	}
+	return ""
+}
+
+// ldStrings return string values from string, object with name/url or array
+func ldStrings(v interface{}) (res []string) {
+	switch t := v.(type) {
+	case string:
+		if s := strings.TrimSpace(t); s != "" {
+			res = append(res, s)
+		}
+	case []interface{}:
+		for _, it := range t {
+			res = append(res, ldStrings(it)...)
+		}
+	case map[string]interface{}:
+		if name := ldString(t["name"]); name != "" {
+			return []string{name}
+		}
+		return ldStrings(t["url"])
+	}
+	return
+}
+
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+func appendUniq(arr []string, s string) []string {
+	if s == "" {
+		return arr
+	}
+	for _, it := range arr {
+		if it == s {
+			return arr
+		}
+	}
+	return append(arr, s)
+}
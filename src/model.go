@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const (
+	modelVersion = 7
+	modelFile    = "model.json"
+)
+
+// Corpus document frequencies and stop words of tfidf
+type Corpus struct {
+	N          int      `json:"n"`
+	StopWords  []string `json:"stop_words"`
+	Vocabulary []string `json:"vocabulary"`
+	DocFreqs   []int    `json:"doc_freqs"`
+}
+
+// Model trained categories and classifiers, idf is calculated on category documents
+type Model struct {
+	Version   int       `json:"version"`
+	Built     time.Time `json:"built"`
+	TrainDir  string    `json:"train_dir"`
+	TrainDocs int       `json:"train_docs"`
+	Taxonomy  []int     `json:"taxonomy"` // category ids of taxonomy model is trained on
+	Threshold float64   `json:"threshold"`
+	Corpus
+	Categories []Category  `json:"categories"`
+	NaiveBayes *NaiveBayes `json:"naive_bayes,omitempty"`
+	LogReg     *LogReg     `json:"logreg,omitempty"`
+	// News is trained if there are not news articles
+	News *NewsClassifier `json:"news,omitempty"`
+}
+
+// BuildModel train categories, news classifier and nb, logreg classifiers from list
+// on dir/<lang>/<id> folders
+func BuildModel(dir string, classifiers ...string) *Model {
+	articles := trainArticles(dir)
+	tf := NewTFIDF(WithStopWords())
+	categs := centroids(tf, categsOf(articles))
+	m := &Model{
+		Version:    modelVersion,
+		Built:      time.Now().UTC(),
+		TrainDir:   dir,
+		Taxonomy:   taxonomyIDs(),
+		Threshold:  categThreshold,
+		Corpus:     corpusOf(tf),
+		Categories: categs,
+	}
+	for _, c := range categs {
+		m.TrainDocs += c.Docs
+	}
+	m.News = trainNewsClassifier(dir, articles)
+	for _, name := range classifiers {
+		switch name {
+		case "nb":
+			m.NaiveBayes = NewNaiveBayes(categs)
+		case "logreg":
+			m.LogReg = NewLogReg(articles)
+		}
+	}
+	return m
+}
+
+// LoadModel read model from file
+func LoadModel(file string) (*Model, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m := &Model{}
+	if err = json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	if m.Version != modelVersion {
+		return nil, fmt.Errorf("model %s: version %d, expected %d, run build-model", file, m.Version, modelVersion)
+	}
+	if !reflect.DeepEqual(m.Taxonomy, taxonomyIDs()) {
+		return nil, fmt.Errorf("model %s: trained on taxonomy %v, current taxonomy %v, run build-model",
+			file, m.Taxonomy, taxonomyIDs())
+	}
+	if len(m.Vocabulary) != len(m.DocFreqs) {
+		return nil, fmt.Errorf("model %s: vocabulary and doc_freqs length mismatch", file)
+	}
+	return m, nil
+}
+
+// loadModel return model from --model file if exists, otherwise model with --classifier
+// trained on train folders, so classification is the same with and without file
+func loadModel() *Model {
+	m, err := LoadModel(optString("model", modelFile))
+	if err == nil {
+		return m
+	}
+	if !os.IsNotExist(err) {
+		checkErr(err)
+	}
+	return BuildModel("train", classifierName())
+}
+
+// Save write model to file
+func (m *Model) Save(file string) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, b, 0644)
+}
+
+// Classifier return classifier selected by --classifier option
+func (m *Model) Classifier() Classifier {
+	name := classifierName()
+	switch name {
+	case "nb":
+		if m.NaiveBayes != nil {
+			m.NaiveBayes.minProb = optFloat("min-prob", defaultNBMinProb)
+			return m.NaiveBayes
+		}
+	case "logreg":
+		if m.LogReg != nil {
+			m.LogReg.minProb = optFloat("min-prob", defaultMinProb)
+			return m.LogReg
+		}
+	case "centroid":
+		return &Centroid{tf: m.TFIDF(), categs: m.Categories, threshold: m.Threshold}
+	}
+	checkErr(fmt.Errorf("model %s: no %s classifier, run build-model", optString("model", modelFile), name))
+	return nil
+}
+
+// NewsClassifier return news classifier with --news-prob, nil if model has no news classifier
+func (m *Model) NewsClassifier() *NewsClassifier {
+	if m.News != nil {
+		m.News.minProb = optFloat("news-prob", defaultMinProb)
+	}
+	return m.News
+}
+
+// corpusOf return document frequencies and stop words of tfidf
+func corpusOf(tf *TFIDF) Corpus {
+	c := Corpus{N: tf.n}
+	for term := range tf.stopWords {
+		c.StopWords = append(c.StopWords, term)
+	}
+	sort.Strings(c.StopWords)
+	for term := range tf.termDocs {
+		c.Vocabulary = append(c.Vocabulary, term)
+	}
+	sort.Strings(c.Vocabulary)
+	c.DocFreqs = make([]int, len(c.Vocabulary))
+	for i, term := range c.Vocabulary {
+		c.DocFreqs[i] = tf.termDocs[term]
+	}
+	return c
+}
+
+// TFIDF return tfidf with corpus document frequencies and stop words
+func (c Corpus) TFIDF() *TFIDF {
+	tf := NewTFIDF()
+	tf.stopWords = make(map[string]interface{})
+	for _, w := range c.StopWords {
+		tf.stopWords[w] = nil
+	}
+	tf.n = c.N
+	makeCorpus(c.Vocabulary)
+	for i, term := range c.Vocabulary {
+		tf.termDocs[term] = c.DocFreqs[i]
+	}
+	return tf
+}
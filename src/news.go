@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	urlSplit = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	urlDate  = regexp.MustCompile(`/(19|20)\d\d[/-]\d\d?([/-]\d\d?)?/`)
+	urlID    = regexp.MustCompile(`\d{5,}`)
+)
+
+// NewsClassifier binary logistic regression of news against not news articles,
+// features are tf-idf of text with url, domain and site name tokens
+type NewsClassifier struct {
+	tf      *TFIDF
+	model   *lrModel
+	minProb float64
+}
+
+// newsJSON news classifier in model file
+type newsJSON struct {
+	Corpus Corpus   `json:"corpus"`
+	Model  *lrModel `json:"model"`
+}
+
+// NewNewsClassifier train classifier on news and not news articles
+func NewNewsClassifier(news, notNews []Article) *NewsClassifier {
+	nc := &NewsClassifier{
+		tf:      NewTFIDF(WithStopWords()),
+		minProb: optFloat("news-prob", defaultMinProb),
+	}
+	samples := make([]lrSample, 0, len(news)+len(notNews))
+	for _, docs := range [][]Article{news, notNews} {
+		for _, a := range docs {
+			nc.tf.AddLangDocs(a.LangCode, a.Words)
+		}
+	}
+	for class, docs := range [][]Article{news, notNews} {
+		for _, a := range docs {
+			samples = append(samples, lrSample{x: nc.features(a), class: class})
+		}
+	}
+	nc.model = fitCalibrated(samples, []int{0, 1})
+	return nc
+}
+
+// trainNewsClassifier train news classifier on categorized articles against
+// dir/<lang>/<folder> folders, nil if there are no not news articles
+func trainNewsClassifier(dir string, news []Article) *NewsClassifier {
+	notNews := make([]Article, 0)
+	for _, l := range []string{"en", "ru"} {
+		files := fmt.Sprintf("%s/%s/%s", dir, l, notNewsFolder)
+		if !hasArticles(files) {
+			continue
+		}
+		notNews = append(notNews, folderArticles(files, l)...)
+	}
+	if len(notNews) == 0 {
+		return nil
+	}
+	return NewNewsClassifier(news, notNews)
+}
+
+// MarshalJSON write document frequencies and model
+func (nc *NewsClassifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newsJSON{Corpus: corpusOf(nc.tf), Model: nc.model})
+}
+
+// UnmarshalJSON read document frequencies and model
+func (nc *NewsClassifier) UnmarshalJSON(b []byte) error {
+	j := newsJSON{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	nc.tf, nc.model = j.Corpus.TFIDF(), j.Model
+	return nil
+}
+
+// features return normalized tf-idf of text joined with normalized url tokens
+func (nc *NewsClassifier) features(a Article) Vector {
+	text := nc.tf.CalLang(a.LangCode, a.Words)
+	tokens := urlTokens(a)
+	ids := make([]int32, 0, len(text.IDs)+len(tokens))
+	weights := make([]float64, 0, len(text.IDs)+len(tokens))
+	for i, id := range text.IDs {
+		ids = append(ids, id)
+		weights = append(weights, text.Weights[i]/math.Max(text.Norm, 1e-12))
+	}
+	// url tokens have the same total weight as text
+	for _, t := range tokens {
+		ids = append(ids, vocab.ID(t))
+		weights = append(weights, 1/math.Sqrt(float64(len(tokens))))
+	}
+	x := newVector(ids, weights)
+	if x.Norm == 0 {
+		return x
+	}
+	for i := range x.Weights {
+		x.Weights[i] /= x.Norm
+	}
+	x.Norm = 1
+	return x
+}
+
+// urlTokens return domain, url path, site name and news heuristic tokens of article,
+// tokens are prefixed so they never match words of text
+func urlTokens(a Article) (res []string) {
+	if a.Domain != "" {
+		res = appendUniq(res, "dom:"+a.Domain)
+	}
+	if u, err := url.Parse(strings.ToLower(a.Href)); err == nil {
+		path := u.Path
+		if urlDate.MatchString(path) {
+			res = appendUniq(res, "url:#date")
+		}
+		if urlID.MatchString(path) {
+			res = appendUniq(res, "url:#id")
+		}
+		depth := 0
+		for _, seg := range strings.Split(path, "/") {
+			if seg != "" {
+				depth++
+			}
+		}
+		res = appendUniq(res, fmt.Sprintf("url:#depth%d", depth))
+		for _, w := range urlSplit.Split(path, -1) {
+			if len(w) > 1 && !urlID.MatchString(w) {
+				res = appendUniq(res, "url:"+w)
+			}
+		}
+	}
+	for _, w := range strings.Fields(strings.ToLower(a.SName)) {
+		res = appendUniq(res, "site:"+w)
+	}
+	if a.IsNews {
+		res = appendUniq(res, "heur:news")
+	}
+	return
+}
+
+// Classify return true if article is news and news probability
+func (nc *NewsClassifier) Classify(a Article) (bool, float64) {
+	p := nc.model.probs(nc.features(a), nc.model.temp)[0]
+	return p >= nc.minProb, p
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// options command line options in --name=value form
+var options = make(map[string]string)
+
+// parseArgs move --name=value arguments to options, return positional arguments
+func parseArgs(args []string) (res []string) {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			res = append(res, arg)
+			continue
+		}
+		arg = strings.TrimPrefix(arg, "--")
+		name, value := arg, "true"
+		if i := strings.Index(arg, "="); i >= 0 {
+			name, value = arg[:i], arg[i+1:]
+		}
+		options[name] = value
+	}
+	return
+}
+
+// optString return option value or default
+func optString(name, def string) string {
+	if v, ok := options[name]; ok {
+		return v
+	}
+	return def
+}
+
+// optInt return int option value or default
+func optInt(name string, def int) int {
+	v, err := strconv.Atoi(optString(name, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// optFloat return float option value or default
+func optFloat(name string, def float64) float64 {
+	v, err := strconv.ParseFloat(optString(name, ""), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// optBool return bool option value or default, --name is true
+func optBool(name string, def bool) bool {
+	v, err := strconv.ParseBool(optString(name, ""))
+	if err != nil {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// stage process article, false drops article from pipeline
+type stage func(a Article) (Article, bool)
+
+type stageJob struct {
+	i int
+	a Article
+}
+
+type stageResult struct {
+	i  int
+	a  Article
+	ok bool
+}
+
+// workers return size of worker pool, --workers or number of cpu
+func workers() int {
+	if n := optInt("workers", runtime.NumCPU()); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// pipeline run stages over articles by bounded pool of workers,
+// each worker runs all stages of one article, result keeps input order.
+// Input is streamed, so only articles in work are held with their content
+func pipeline(in <-chan Article, stages ...stage) []Article {
+	jobs := make(chan stageJob)
+	results := make(chan stageResult)
+	var wg sync.WaitGroup
+	for w := 0; w < workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				a, ok := j.a, true
+				for _, s := range stages {
+					if a, ok = s(a); !ok {
+						break
+					}
+				}
+				results <- stageResult{i: j.i, a: a, ok: ok}
+			}
+		}()
+	}
+	go func() {
+		i := 0
+		for a := range in {
+			jobs <- stageJob{i: i, a: a}
+			i++
+		}
+		close(jobs)
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	done := make([]stageResult, 0)
+	for r := range results {
+		for len(done) <= r.i {
+			done = append(done, stageResult{})
+		}
+		done[r.i] = r
+	}
+	out := make([]Article, 0, len(done))
+	for _, r := range done {
+		if r.ok {
+			out = append(out, r.a)
+		}
+	}
+	return out
+}
+
+// walkArticles send articles for files in dir sorted by file name,
+// dir may be tar, tar.gz or zip archive or folder in it, like data.zip/en/0
+func walkArticles(dir string) <-chan Article {
+	out := make(chan Article)
+	if archive, folder := splitArchive(dir); archive != "" {
+		return archiveArticles(archive, folder)
+	}
+	list, err := filePathWalkDir(dir)
+	if err != nil {
+		panic(err)
+	}
+	sort.Strings(list)
+	go func() {
+		for _, f := range list {
+			out <- Article{Name: filepath.Base(f), File: f}
+		}
+		close(out)
+	}()
+	return out
+}
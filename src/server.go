@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Index in-memory article index for server mode
+type Index struct {
+	sync.RWMutex
+	articles map[string]indexed
+	clf      Classifier
+	news     *NewsClassifier
+	imp      *Importance // authority is read once on start
+	terms    int         // vocab size of model, terms of requests are removed after request
+}
+
+type indexed struct {
+	Article Article
+	Added   time.Time
+	Expire  time.Time
+}
+
+// Time return published time or time of indexing
+func (it indexed) Time() time.Time {
+	if !it.Article.Published.IsZero() {
+		return it.Article.Published
+	}
+	return it.Added
+}
+
+// NewIndex new index with trained categories
+func NewIndex() *Index {
+	m := loadModel()
+	idx := &Index{
+		articles: make(map[string]indexed),
+		clf:      m.Classifier(),
+		news:     m.NewsClassifier(),
+		imp:      NewImportance(),
+	}
+	idx.terms = vocab.Len()
+	return idx
+}
+
+var maxAge = regexp.MustCompile(`max-age=(\d+)`)
+
+// ttl return max-age from Cache-Control header
+func ttl(cacheControl string) time.Duration {
+	m := maxAge.FindStringSubmatch(cacheControl)
+	if len(m) < 2 {
+		return 0
+	}
+	sec, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// Put parse html and add article to index, return true if article is new
+func (idx *Index) Put(name string, body []byte, ttl time.Duration) (bool, error) {
+	body, charset := toUTF8(body)
+	m, err := infoReader(bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	a := parsedArticle(Article{Name: name, File: name, Charset: charset}, m)
+
+	idx.Lock()
+	defer idx.Unlock()
+	// indexed articles keep no vectors, so vocab does not grow with index
+	defer vocab.Truncate(idx.terms)
+	a.CategoryId = -1
+	if idx.news != nil && a.LangCode != "" {
+		// not news articles are indexed without category and are not in threads
+		a.IsNews, _ = idx.news.Classify(a)
+	}
+	if a.LangCode != "" && (idx.news == nil || a.IsNews) {
+		a.CategoryId, a.Scores = idx.clf.Classify(a)
+	}
+	if idx.news == nil {
+		a.IsNews = a.CategoryId != -1
+	}
+
+	now := time.Now()
+	it := indexed{Article: a, Added: now}
+	if ttl > 0 {
+		it.Expire = now.Add(ttl)
+	}
+	idx.purge(now)
+	_, ok := idx.articles[name]
+	idx.articles[name] = it
+	return !ok, nil
+}
+
+// Delete remove article from index, return false if not found
+func (idx *Index) Delete(name string) bool {
+	idx.Lock()
+	defer idx.Unlock()
+	idx.purge(time.Now())
+	if _, ok := idx.articles[name]; !ok {
+		return false
+	}
+	delete(idx.articles, name)
+	return true
+}
+
+// purge remove expired articles, must be called under lock
+func (idx *Index) purge(now time.Time) {
+	for name, it := range idx.articles {
+		if !it.Expire.IsZero() && now.After(it.Expire) {
+			delete(idx.articles, name)
+		}
+	}
+}
+
+// Threads return ranked threads for news articles published in period
+// before the latest indexed article
+func (idx *Index) Threads(period time.Duration, lang, categ string) []ByThread {
+	idx.Lock()
+	defer idx.Unlock()
+	// vectors of threads are not kept after request
+	defer vocab.Truncate(idx.terms)
+	idx.purge(time.Now())
+	var latest time.Time
+	for _, it := range idx.articles {
+		if t := it.Time(); t.After(latest) {
+			latest = t
+		}
+	}
+	articles := make([]Article, 0, len(idx.articles))
+	for _, it := range idx.articles {
+		a := it.Article
+		if a.CategoryId == -1 {
+			continue
+		}
+		if lang != "" && a.LangCode != lang {
+			continue
+		}
+		if categ != "" && categ != "any" && categName(a.CategoryId) != categ {
+			continue
+		}
+		if period > 0 && latest.Sub(it.Time()) > period {
+			continue
+		}
+		// recency of articles without published time is counted from indexing
+		a.Published = it.Time()
+		articles = append(articles, a)
+	}
+
+	sort.Slice(articles, func(i, j int) bool {
+		return articles[i].Name < articles[j].Name
+	})
+	allpairs := chunkPairs(articles)
+	tops := rankPairs(allpairs, idx.imp)
+	related := relatedThreads(allpairs)
+	byThreads := make([]ByThread, 0, len(tops))
+	for _, t := range tops {
+		byThreads = append(byThreads, threadOfTop(t, related))
+	}
+	return byThreads
+}
+
+// ServeHTTP handle PUT /<article_name>, DELETE /<article_name> and GET /threads
+func (idx *Index) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && name == "threads":
+		q := r.URL.Query()
+		period, _ := strconv.Atoi(q.Get("period"))
+		categ := q.Get("category")
+		if categ == "" {
+			categ = "any"
+		}
+		bytop := ByTop{Category: categ}
+		bytop.Threads = idx.Threads(time.Duration(period)*time.Second, q.Get("lang_code"), categ)
+		b, err := json.MarshalIndent(bytop, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	case r.Method == http.MethodPut && name == "threads":
+		http.Error(w, "threads is reserved name", http.StatusBadRequest)
+	case r.Method == http.MethodPut && name != "":
+		max := int64(optInt("max-entry-size", defaultMaxEntrySize))
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, max))
+		if err != nil && int64(len(body)) >= max {
+			http.Error(w, "article is over --max-entry-size", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		created, err := idx.Put(name, body, ttl(r.Header.Get("Cache-Control")))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if created {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && name != "":
+		if !idx.Delete(name) {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func server(port string) {
+	idx := NewIndex()
+	if isDebug {
+		fmt.Printf("listen on :%s\n", port)
+	}
+	checkErr(http.ListenAndServe(":"+port, idx))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Vocab interned terms, term id is position in terms
+type Vocab struct {
+	sync.RWMutex
+	ids   map[string]int32
+	terms []string
+}
+
+// Vector sparse tf-idf vector, term ids are sorted
+type Vector struct {
+	IDs     []int32
+	Weights []float64
+	Norm    float64 // L2 norm
+}
+
+// vocab shared by all vectors, so vectors from different TFIDF are comparable
+var vocab = NewVocab()
+
+// NewVocab new empty vocabulary
+func NewVocab() *Vocab {
+	return &Vocab{ids: make(map[string]int32)}
+}
+
+// ID return term id, new terms are added
+func (v *Vocab) ID(term string) int32 {
+	v.RLock()
+	id, ok := v.ids[term]
+	v.RUnlock()
+	if ok {
+		return id
+	}
+	v.Lock()
+	defer v.Unlock()
+	if id, ok = v.ids[term]; ok {
+		return id
+	}
+	id = int32(len(v.terms))
+	v.ids[term] = id
+	v.terms = append(v.terms, term)
+	return id
+}
+
+// Len return number of terms
+func (v *Vocab) Len() int {
+	v.RLock()
+	defer v.RUnlock()
+	return len(v.terms)
+}
+
+// Truncate remove terms added after first n terms, vectors with removed terms
+// must not be used after it
+func (v *Vocab) Truncate(n int) {
+	v.Lock()
+	defer v.Unlock()
+	for i := n; i < len(v.terms); i++ {
+		delete(v.ids, v.terms[i])
+		v.terms[i] = ""
+	}
+	if n < len(v.terms) {
+		v.terms = v.terms[:n]
+	}
+}
+
+// Term return term by id
+func (v *Vocab) Term(id int32) string {
+	v.RLock()
+	defer v.RUnlock()
+	return v.terms[id]
+}
+
+// Vector return sparse vector for term weights, new terms are added in sorted order
+func (v *Vocab) Vector(m map[string]float64) Vector {
+	terms := make([]string, 0, len(m))
+	for term := range m {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	ids := make([]int32, 0, len(m))
+	weights := make([]float64, 0, len(m))
+	for _, term := range terms {
+		ids = append(ids, v.ID(term))
+		weights = append(weights, m[term])
+	}
+	return newVector(ids, weights)
+}
+
+// newVector sort ids with weights and calculate norm
+func newVector(ids []int32, weights []float64) Vector {
+	vec := Vector{IDs: ids, Weights: weights}
+	sort.Sort(byID(vec))
+	for _, w := range vec.Weights {
+		vec.Norm += w * w
+	}
+	vec.Norm = math.Sqrt(vec.Norm)
+	return vec
+}
+
+type byID Vector
+
+func (s byID) Len() int           { return len(s.IDs) }
+func (s byID) Less(i, j int) bool { return s.IDs[i] < s.IDs[j] }
+func (s byID) Swap(i, j int) {
+	s.IDs[i], s.IDs[j] = s.IDs[j], s.IDs[i]
+	s.Weights[i], s.Weights[j] = s.Weights[j], s.Weights[i]
+}
+
+// Map return term weights of vector
+func (vec Vector) Map() map[string]float64 {
+	m := make(map[string]float64, len(vec.IDs))
+	for i, id := range vec.IDs {
+		m[vocab.Term(id)] = vec.Weights[i]
+	}
+	return m
+}
+
+// Dot return dot product of vectors by merge join of sorted ids
+func (vec Vector) Dot(b Vector) (product float64) {
+	i, j := 0, 0
+	for i < len(vec.IDs) && j < len(b.IDs) {
+		switch {
+		case vec.IDs[i] == b.IDs[j]:
+			product += vec.Weights[i] * b.Weights[j]
+			i++
+			j++
+		case vec.IDs[i] < b.IDs[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return
+}
+
+// MarshalJSON write vector as term weights
+func (vec Vector) MarshalJSON() ([]byte, error) {
+	return json.Marshal(vec.Map())
+}
+
+// UnmarshalJSON read vector from term weights
+func (vec *Vector) UnmarshalJSON(b []byte) error {
+	m := make(map[string]float64)
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	*vec = vocab.Vector(m)
+	return nil
+}
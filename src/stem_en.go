@@ -0,0 +1,270 @@
+package main
+
+import (
+	"strings"
+)
+
+// Porter2Tokenizer split text by spaces and stem english words with porter2 algorithm
+type Porter2Tokenizer struct {
+}
+
+var enStems = newStemCache() // word -> stem cache
+
+// porter2 exceptions, see http://snowball.tartarus.org/algorithms/english/stemmer.html
+var (
+	enExceptions = map[string]string{
+		"skis": "ski", "skies": "sky", "dying": "die", "lying": "lie", "tying": "tie",
+		"idly": "idl", "gently": "gentl", "ugly": "ugli", "early": "earli", "only": "onli", "singly": "singl",
+		"sky": "sky", "news": "news", "howe": "howe", "atlas": "atlas", "cosmos": "cosmos", "bias": "bias", "andes": "andes",
+	}
+	enExceptions1a = map[string]bool{
+		"inning": true, "outing": true, "canning": true, "herring": true,
+		"earring": true, "proceed": true, "exceed": true, "succeed": true,
+	}
+	enStep2 = map[string]string{
+		"tional": "tion", "enci": "ence", "anci": "ance", "abli": "able", "entli": "ent",
+		"izer": "ize", "ization": "ize", "ational": "ate", "ation": "ate", "ator": "ate",
+		"alism": "al", "aliti": "al", "alli": "al", "fulness": "ful", "ousli": "ous", "ousness": "ous",
+		"iveness": "ive", "iviti": "ive", "biliti": "ble", "bli": "ble", "ogi": "og",
+		"fulli": "ful", "lessli": "less", "li": "",
+	}
+	enStep3 = map[string]string{
+		"tional": "tion", "ational": "ate", "alize": "al", "icate": "ic", "iciti": "ic",
+		"ical": "ic", "ful": "", "ness": "", "ative": "",
+	}
+	enStep4 = []string{"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement",
+		"ment", "ent", "ism", "ate", "iti", "ous", "ive", "ize", "ion"}
+)
+
+func (s *Porter2Tokenizer) Seg(text string) []string {
+	res := strings.Fields(text)
+	for i, w := range res {
+		res[i] = enStem(w)
+	}
+	return res
+}
+
+func (s *Porter2Tokenizer) Free() {
+
+}
+
+// enStem return stem of english word, words with other letters returned as is
+func enStem(word string) string {
+	if stem, ok := enStems.Load(word); ok {
+		return stem
+	}
+	w := []byte(strings.ToLower(word))
+	for _, c := range w {
+		if (c < 'a' || c > 'z') && c != '\'' {
+			return word
+		}
+	}
+	stem := porter2(w)
+	enStems.Store(word, stem)
+	return stem
+}
+
+func porter2(w []byte) string {
+	if len(w) > 0 && w[0] == '\'' {
+		w = w[1:]
+	}
+	if len(w) <= 2 {
+		return string(w)
+	}
+	if ex, ok := enExceptions[string(w)]; ok {
+		return ex
+	}
+	for i := range w {
+		if w[i] == 'y' && (i == 0 || isEnVowel(w[i-1])) {
+			w[i] = 'Y'
+		}
+	}
+	r1, r2 := enRegions(w)
+
+	// step 0
+	for _, suf := range []string{"'s'", "'s", "'"} {
+		if endsWith(w, suf) {
+			w = w[:len(w)-len(suf)]
+			break
+		}
+	}
+
+	// step 1a
+	switch {
+	case endsWith(w, "sses"):
+		w = w[:len(w)-2]
+	case endsWith(w, "ied"), endsWith(w, "ies"):
+		if len(w) > 4 {
+			w = w[:len(w)-2]
+		} else {
+			w = w[:len(w)-1]
+		}
+	case endsWith(w, "us"), endsWith(w, "ss"):
+	case endsWith(w, "s"):
+		// step 0 may leave just "s"
+		if len(w) > 2 && hasVowel(w[:len(w)-2]) {
+			w = w[:len(w)-1]
+		}
+	}
+	if enExceptions1a[string(w)] {
+		return string(w)
+	}
+
+	// step 1b
+	switch suf := longest(w, "eedly", "ingly", "edly", "eed", "ing", "ed"); suf {
+	case "eed", "eedly":
+		if len(w)-len(suf) >= r1 {
+			w = append(w[:len(w)-len(suf)], "ee"...)
+		}
+	case "ed", "edly", "ing", "ingly":
+		if hasVowel(w[:len(w)-len(suf)]) {
+			w = w[:len(w)-len(suf)]
+			switch {
+			case endsWith(w, "at"), endsWith(w, "bl"), endsWith(w, "iz"):
+				w = append(w, 'e')
+			case isDouble(w):
+				w = w[:len(w)-1]
+			case isShortWord(w, r1):
+				w = append(w, 'e')
+			}
+		}
+	}
+
+	// step 1c
+	if l := len(w); l > 2 && (w[l-1] == 'y' || w[l-1] == 'Y') && !isEnVowel(w[l-2]) {
+		w[l-1] = 'i'
+	}
+
+	// step 2
+	if suf := longestKey(w, enStep2); suf != "" && len(w)-len(suf) >= r1 {
+		base := w[:len(w)-len(suf)]
+		switch suf {
+		case "ogi":
+			if endsWith(base, "l") {
+				w = append(base, "og"...)
+			}
+		case "li":
+			if l := len(base); l > 0 && strings.IndexByte("cdeghkmnrt", base[l-1]) >= 0 {
+				w = base
+			}
+		default:
+			w = append(base, enStep2[suf]...)
+		}
+	}
+
+	// step 3
+	if suf := longestKey(w, enStep3); suf != "" && len(w)-len(suf) >= r1 {
+		if suf != "ative" || len(w)-len(suf) >= r2 {
+			w = append(w[:len(w)-len(suf)], enStep3[suf]...)
+		}
+	}
+
+	// step 4
+	if suf := longest(w, enStep4...); suf != "" && len(w)-len(suf) >= r2 {
+		base := w[:len(w)-len(suf)]
+		if suf != "ion" || endsWith(base, "s") || endsWith(base, "t") {
+			w = base
+		}
+	}
+
+	// step 5
+	if l := len(w); l > 0 {
+		switch w[l-1] {
+		case 'e':
+			if l-1 >= r2 || (l-1 >= r1 && !endsShortSyllable(w[:l-1])) {
+				w = w[:l-1]
+			}
+		case 'l':
+			if l-1 >= r2 && l > 1 && w[l-2] == 'l' {
+				w = w[:l-1]
+			}
+		}
+	}
+	return strings.Replace(string(w), "Y", "y", -1)
+}
+
+func isEnVowel(c byte) bool {
+	return strings.IndexByte("aeiouy", c) >= 0
+}
+
+// enRegions return start of R1 and R2 regions
+func enRegions(w []byte) (r1, r2 int) {
+	r1, r2 = len(w), len(w)
+	s := string(w)
+	switch {
+	case strings.HasPrefix(s, "gener"), strings.HasPrefix(s, "arsen"):
+		r1 = 5
+	case strings.HasPrefix(s, "commun"):
+		r1 = 6
+	default:
+		for i := 1; i < len(w); i++ {
+			if !isEnVowel(w[i]) && isEnVowel(w[i-1]) {
+				r1 = i + 1
+				break
+			}
+		}
+	}
+	for i := r1 + 1; i < len(w); i++ {
+		if !isEnVowel(w[i]) && isEnVowel(w[i-1]) {
+			r2 = i + 1
+			break
+		}
+	}
+	return
+}
+
+func endsWith(w []byte, suf string) bool {
+	return strings.HasSuffix(string(w), suf)
+}
+
+// longest return longest suffix of word from list
+func longest(w []byte, list ...string) (res string) {
+	for _, suf := range list {
+		if len(suf) > len(res) && endsWith(w, suf) {
+			res = suf
+		}
+	}
+	return
+}
+
+func longestKey(w []byte, m map[string]string) (res string) {
+	for suf := range m {
+		if len(suf) > len(res) && endsWith(w, suf) {
+			res = suf
+		}
+	}
+	return
+}
+
+func hasVowel(w []byte) bool {
+	for _, c := range w {
+		if isEnVowel(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDouble(w []byte) bool {
+	l := len(w)
+	if l < 2 || w[l-1] != w[l-2] {
+		return false
+	}
+	return strings.IndexByte("bdfgmnprt", w[l-1]) >= 0
+}
+
+func endsShortSyllable(w []byte) bool {
+	l := len(w)
+	if l == 2 {
+		return isEnVowel(w[0]) && !isEnVowel(w[1])
+	}
+	if l >= 3 {
+		return !isEnVowel(w[l-3]) && isEnVowel(w[l-2]) && !isEnVowel(w[l-1]) &&
+			strings.IndexByte("wxY", w[l-1]) < 0
+	}
+	return false
+}
+
+func isShortWord(w []byte, r1 int) bool {
+	return r1 >= len(w) && endsShortSyllable(w)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// RuTokenizer split text by spaces and stem russian words with snowball algorithm,
+// words in other alphabets are returned as is
+type RuTokenizer struct {
+}
+
+var ruStems = newStemCache() // word -> stem cache
+
+type ending struct {
+	suffix []rune
+	afterA bool // must be preceded by а or я
+}
+
+func endings(afterA []string, other ...string) (res []ending) {
+	for _, s := range afterA {
+		res = append(res, ending{suffix: []rune(s), afterA: true})
+	}
+	for _, s := range other {
+		res = append(res, ending{suffix: []rune(s)})
+	}
+	return
+}
+
+// snowball russian endings, see http://snowball.tartarus.org/algorithms/russian/stemmer.html
+var (
+	ruGerund = endings([]string{"в", "вши", "вшись"},
+		"ив", "ивши", "ившись", "ыв", "ывши", "ывшись")
+	ruAdjective = endings(nil,
+		"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею")
+	ruParticiple = endings([]string{"ем", "нн", "вш", "ющ", "щ"},
+		"ивш", "ывш", "ующ")
+	ruReflexive = endings(nil, "ся", "сь")
+	ruVerb      = endings([]string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"},
+		"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен",
+		"ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю")
+	ruNoun = endings(nil,
+		"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й",
+		"иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я")
+	ruSuperlative  = endings(nil, "ейш", "ейше")
+	ruDerivational = endings(nil, "ост", "ость")
+)
+
+func (s *RuTokenizer) Seg(text string) []string {
+	res := strings.Fields(text)
+	for i, w := range res {
+		res[i] = ruStem(w)
+	}
+	return res
+}
+
+func (s *RuTokenizer) Free() {
+
+}
+
+// ruStem return stem of russian word, other words returned as is
+func ruStem(word string) string {
+	if stem, ok := ruStems.Load(word); ok {
+		return stem
+	}
+	w := []rune(strings.ToLower(word))
+	for i, r := range w {
+		if r == 'ё' {
+			w[i] = 'е'
+		}
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Cyrillic, r) {
+			return word
+		}
+	}
+	stem := string(ruStemRunes(w))
+	ruStems.Store(word, stem)
+	return stem
+}
+
+func ruStemRunes(w []rune) []rune {
+	rv, r2 := ruRegions(w)
+	if rv >= len(w) {
+		return w
+	}
+
+	// step 1
+	if res, ok := cut(w, rv, ruGerund); ok {
+		w = res
+	} else {
+		if res, ok := cut(w, rv, ruReflexive); ok {
+			w = res
+		}
+		if res, ok := cut(w, rv, ruAdjective); ok {
+			w = res
+			if res, ok := cut(w, rv, ruParticiple); ok {
+				w = res
+			}
+		} else if res, ok := cut(w, rv, ruVerb); ok {
+			w = res
+		} else if res, ok := cut(w, rv, ruNoun); ok {
+			w = res
+		}
+	}
+
+	// step 2
+	if len(w) > rv && w[len(w)-1] == 'и' {
+		w = w[:len(w)-1]
+	}
+
+	// step 3
+	if res, ok := cut(w, r2, ruDerivational); ok {
+		w = res
+	}
+
+	// step 4
+	if res, ok := cut(w, rv, ruSuperlative); ok {
+		w = res
+		if hasSuffix(w, rv, []rune("нн")) {
+			w = w[:len(w)-1]
+		}
+	} else if hasSuffix(w, rv, []rune("нн")) {
+		w = w[:len(w)-1]
+	} else if len(w) > rv && w[len(w)-1] == 'ь' {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// cut remove longest ending lying in region started at pos
+func cut(w []rune, pos int, list []ending) ([]rune, bool) {
+	best := -1
+	for i, e := range list {
+		if !hasSuffix(w, pos, e.suffix) {
+			continue
+		}
+		if best < 0 || len(e.suffix) > len(list[best].suffix) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return w, false
+	}
+	e := list[best]
+	start := len(w) - len(e.suffix)
+	if e.afterA {
+		if start-1 < pos || (w[start-1] != 'а' && w[start-1] != 'я') {
+			return w, false
+		}
+	}
+	return w[:start], true
+}
+
+func hasSuffix(w []rune, pos int, suffix []rune) bool {
+	start := len(w) - len(suffix)
+	if start < pos || start < 0 {
+		return false
+	}
+	for i, r := range suffix {
+		if w[start+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func isRuVowel(r rune) bool {
+	return strings.ContainsRune("аеиоуыэюя", r)
+}
+
+// ruRegions return start of RV and R2 regions
+func ruRegions(w []rune) (rv, r2 int) {
+	rv, r1 := len(w), len(w)
+	r2 = len(w)
+	for i, r := range w {
+		if isRuVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	for i := 1; i < len(w); i++ {
+		if !isRuVowel(w[i]) && isRuVowel(w[i-1]) {
+			r1 = i + 1
+			break
+		}
+	}
+	for i := r1 + 1; i < len(w); i++ {
+		if !isRuVowel(w[i]) && isRuVowel(w[i-1]) {
+			r2 = i + 1
+			break
+		}
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+// from snowball russian sample vocabulary, http://snowball.tartarus.org/algorithms/russian/stemmer.html
+func TestRuStem(t *testing.T) {
+	tests := []struct {
+		word, stem string
+	}{
+		{"в", "в"},
+		{"вавиловка", "вавиловк"},
+		{"вагнера", "вагнер"},
+		{"вагон", "вагон"},
+		{"вагона", "вагон"},
+		{"вагоне", "вагон"},
+		{"вагонов", "вагон"},
+		{"вагоном", "вагон"},
+		{"вагоны", "вагон"},
+		{"важная", "важн"},
+		{"важнее", "важн"},
+		{"важнейшие", "важн"},
+		{"важнейшими", "важн"},
+		{"важничал", "важнича"},
+		{"важно", "важн"},
+		{"важного", "важн"},
+		{"важное", "важн"},
+		{"важной", "важн"},
+		{"важном", "важн"},
+		{"важному", "важн"},
+		{"важности", "важност"},
+		{"важностию", "важност"},
+		{"важность", "важност"},
+		{"важностью", "важност"},
+		{"важную", "важн"},
+		{"важны", "важн"},
+		{"важные", "важн"},
+		{"важный", "важн"},
+		{"важным", "важн"},
+		{"вазах", "ваз"},
+		{"вазы", "ваз"},
+		{"вакса", "вакс"},
+		{"вакханка", "вакханк"},
+		{"вал", "вал"},
+		{"валандался", "валанда"},
+		{"валентина", "валентин"},
+		{"валериановых", "валерианов"},
+		{"валерию", "валер"},
+		{"валетами", "валет"},
+		{"вали", "вал"},
+		{"валил", "вал"},
+		{"валился", "вал"},
+		{"валится", "вал"},
+		{"валов", "вал"},
+		{"вальдшнепа", "вальдшнеп"},
+		{"вальс", "вальс"},
+		{"вальса", "вальс"},
+		{"вальсе", "вальс"},
+		{"вальсишку", "вальсишк"},
+		{"вальтера", "вальтер"},
+		{"валяется", "валя"},
+		{"валялась", "валя"},
+		{"валялись", "валя"},
+		{"валялось", "валя"},
+		{"валялся", "валя"},
+		{"валять", "валя"},
+		{"валяются", "валя"},
+		{"вам", "вам"},
+		{"вами", "вам"},
+	}
+	for _, tt := range tests {
+		if stem := ruStem(tt.word); stem != tt.stem {
+			t.Errorf("ruStem(%q) = %q, want %q", tt.word, stem, tt.stem)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "sync"
+
+const stemCacheSize = 100000 // max words of stem cache
+
+// stemCache word -> stem cache, cleared when it is full,
+// so server does not keep stems of all words it has seen
+type stemCache struct {
+	sync.RWMutex
+	stems map[string]string
+}
+
+func newStemCache() *stemCache {
+	return &stemCache{stems: make(map[string]string)}
+}
+
+// Load return cached stem of word
+func (c *stemCache) Load(word string) (string, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	stem, ok := c.stems[word]
+	return stem, ok
+}
+
+// Store add stem of word to cache
+func (c *stemCache) Store(word, stem string) {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.stems) >= stemCacheSize {
+		c.stems = make(map[string]string)
+	}
+	c.stems[word] = stem
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const stopWordsDir = "stopwords"
+
+var (
+	enStopWords = `a about above after again against all also although am among an and another any are around as at
+	be because been before being below between both but by can cannot could did do does doing down during each
+	either even ever every few for from further had has have having he her here hers herself him himself his how
+	however i if in into is it its itself just last like many may me might more most much must my myself near
+	neither no nor not now of off often on once one only or other others our ours ourselves out over own per
+	perhaps rather said same says she should since so some still such than that the their theirs them themselves
+	then there these they this those though through thus to told too under until up upon us very via was we were
+	what whatever when where whether which while who whom whose why will with within without would yet you your
+	yours yourself yourselves according reportedly`
+	ruStopWords = `а без более будет будут бы был была были было быть в вам вас весь во вот все всего всей всех всю
+	вы где да даже для до его ее её если есть еще ещё же за здесь и из или им именно их к как какой когда кроме
+	кто ли либо лишь между меня мне много может можно мы на над надо наш наша наши не него нее неё нет ни них но
+	ну о об однако он она они оно от очень по под после потом почти при про раз с сам свой своей своих свои себе
+	себя сейчас со так также такой там те тем теперь то тогда того тоже только том тот тут ты у уж уже хотя чего
+	чем через что чтобы чье чья эта эти этим этих это этого этой этом этот я который которая которое которые
+	которых которого которой котором которым которыми заявил заявила сообщил сообщила сообщает отметил отметила
+	сказал сказала является являются пока около среди вместе`
+	builtinStopWords = map[string]string{
+		"en": enStopWords,
+		"ru": ruStopWords,
+	}
+
+	stopWordsMu    sync.Mutex
+	stopWordsCache = make(map[string]map[string]interface{})
+)
+
+// TFIDFOption option for NewTFIDF
+type TFIDFOption func(f *TFIDF)
+
+// WithStopWords filter stop words of registered languages in documents
+func WithStopWords() TFIDFOption {
+	return func(f *TFIDF) {
+		f.stopWords = stemmedStopWords()
+	}
+}
+
+// stemmedStopWords return stop words of registered languages which are their own stems.
+// Other stop words are filtered by surface form in bigwords, their stems are not added
+// as they are stems of content words too, like reportedly and report
+func stemmedStopWords() map[string]interface{} {
+	res := make(map[string]interface{})
+	for lang, tokenizer := range tokenizers {
+		for w := range stopWordsFor(lang) {
+			if stems := tokenizer.Seg(w); len(stems) == 1 && stems[0] == w {
+				res[w] = nil
+			}
+		}
+	}
+	return res
+}
+
+// stopWordsFor return built-in stop words for language with words from stopwords/<lang>.txt
+func stopWordsFor(lang string) map[string]interface{} {
+	stopWordsMu.Lock()
+	defer stopWordsMu.Unlock()
+	if m, ok := stopWordsCache[lang]; ok {
+		return m
+	}
+	m := make(map[string]interface{})
+	for _, w := range strings.Fields(builtinStopWords[lang]) {
+		m[w] = nil
+	}
+	words, err := readStopWords(filepath.Join(stopWordsDir, lang+".txt"))
+	if err != nil && !os.IsNotExist(err) {
+		checkErr(err)
+	}
+	for _, w := range words {
+		m[w] = nil
+	}
+	stopWordsCache[lang] = m
+	return m
+}
+
+// readStopWords read words from file, one or more per line, # starts comment
+func readStopWords(file string) (words []string, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		for _, w := range strings.Fields(line) {
+			words = append(words, strings.ToLower(w))
+		}
+	}
+	return words, scanner.Err()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const taxonomyFile = "taxonomy.json"
+
+// Taxon category of taxonomy, article category index is taxon position in taxonomy
+type Taxon struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Folder string `json:"folder"`           // train folder in train/<lang>/
+	Parent int    `json:"parent,omitempty"` // parent taxon id
+}
+
+// taxonomy categories in output order, loaded at startup from --taxonomy file
+var taxonomy = []Taxon{
+	{ID: 1, Name: "society", Folder: "1"},
+	{ID: 2, Name: "economy", Folder: "2"},
+	{ID: 3, Name: "technology", Folder: "3"},
+	{ID: 4, Name: "sports", Folder: "4"},
+	{ID: 5, Name: "entertainment", Folder: "5"},
+	{ID: 6, Name: "science", Folder: "6"},
+	{ID: 7, Name: "other", Folder: "7"},
+}
+
+// train folders which are not categories, set by taxonomy file
+var (
+	notNewsFolder = "8" // train/<lang>/<folder> holds articles which are not news
+	skipFolder    = "9" // train command answer to skip article
+)
+
+const stopAnswer = "0" // train command answer to stop
+
+// TaxonomyConfig taxonomy file, categories with folders reserved for not news
+// and skipped articles, plain list of categories is also accepted
+type TaxonomyConfig struct {
+	Categories    []Taxon `json:"categories"`
+	NotNewsFolder string  `json:"not_news_folder,omitempty"`
+	SkipFolder    string  `json:"skip_folder,omitempty"`
+}
+
+// LoadTaxonomy read taxonomy from json file, folders of categories must be unique
+// and differ from not news and skip folders
+func LoadTaxonomy(file string) (*TaxonomyConfig, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tc := &TaxonomyConfig{}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		err = json.Unmarshal(b, &tc.Categories)
+	} else {
+		err = json.Unmarshal(b, tc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tc.NotNewsFolder == "" {
+		tc.NotNewsFolder = notNewsFolder
+	}
+	if tc.SkipFolder == "" {
+		tc.SkipFolder = skipFolder
+	}
+	if len(tc.Categories) == 0 {
+		return nil, fmt.Errorf("taxonomy %s: no categories", file)
+	}
+	if tc.NotNewsFolder == tc.SkipFolder || tc.NotNewsFolder == stopAnswer || tc.SkipFolder == stopAnswer {
+		return nil, fmt.Errorf("taxonomy %s: not news folder %q, skip folder %q and %q must differ",
+			file, tc.NotNewsFolder, tc.SkipFolder, stopAnswer)
+	}
+	ids := make(map[int]bool)
+	folders := map[string]string{
+		tc.NotNewsFolder: "not news", tc.SkipFolder: "skip", stopAnswer: "stop",
+	}
+	for _, t := range tc.Categories {
+		if ids[t.ID] {
+			return nil, fmt.Errorf("taxonomy %s: duplicate id %d", file, t.ID)
+		}
+		if t.Name == "" || t.Folder == "" {
+			return nil, fmt.Errorf("taxonomy %s: id %d without name or folder", file, t.ID)
+		}
+		if used, ok := folders[t.Folder]; ok {
+			return nil, fmt.Errorf("taxonomy %s: id %d folder %q is used by %s", file, t.ID, t.Folder, used)
+		}
+		ids[t.ID] = true
+		folders[t.Folder] = t.Name
+	}
+	for _, t := range tc.Categories {
+		if t.Parent != 0 && !ids[t.Parent] {
+			return nil, fmt.Errorf("taxonomy %s: id %d has unknown parent %d", file, t.ID, t.Parent)
+		}
+	}
+	return tc, nil
+}
+
+// initTaxonomy replace built-in taxonomy with --taxonomy file if exists
+func initTaxonomy() {
+	tc, err := LoadTaxonomy(optString("taxonomy", taxonomyFile))
+	if err == nil {
+		taxonomy = tc.Categories
+		notNewsFolder = tc.NotNewsFolder
+		skipFolder = tc.SkipFolder
+		return
+	}
+	if !os.IsNotExist(err) {
+		checkErr(err)
+	}
+}
+
+// taxonomyIDs return ids of categories in taxonomy order
+func taxonomyIDs() []int {
+	ids := make([]int, len(taxonomy))
+	for i, t := range taxonomy {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+// taxonIndex return category index of taxon id or -1
+func taxonIndex(id int) int {
+	for i, t := range taxonomy {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// categName return category name by index
+func categName(idx int) string {
+	if idx < 0 || idx >= len(taxonomy) {
+		return ""
+	}
+	return taxonomy[idx].Name
+}
+
+// categParent return parent category name by index or ""
+func categParent(idx int) string {
+	if idx < 0 || idx >= len(taxonomy) || taxonomy[idx].Parent == 0 {
+		return ""
+	}
+	return categName(taxonIndex(taxonomy[idx].Parent))
+}
+
+// categOf return trained category for language and category index or nil
+func categOf(categs []Category, lang string, idx int) *Category {
+	if idx < 0 || idx >= len(taxonomy) {
+		return nil
+	}
+	for i := range categs {
+		if categs[i].LangCode == lang && categs[i].ID == taxonomy[idx].ID {
+			return &categs[i]
+		}
+	}
+	return nil
+}
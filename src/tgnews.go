@@ -6,17 +6,17 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
 	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"sort"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -24,7 +24,12 @@ import (
 )
 
 const (
-	isDebug = false
+	isDebug     = false
+	langTextLen = 2048 // bytes of text for language detection
+)
+
+var (
+	categThreshold = float64(0.555) // min similarity with category
 )
 
 type Tokenizer interface {
@@ -42,7 +47,7 @@ type TFIDF struct {
 	termDocs  map[string]int         // documents number for each term in train data
 	n         int                    // number of documents in train data
 	stopWords map[string]interface{} // words to be filtered
-	tokenizer Tokenizer              // tokenizer, space is used as default
+	tokenizer Tokenizer              // tokenizer for documents without language, space is used as default
 }
 type ByLang struct {
 	LangCode string   `json:"lang_code"`
@@ -53,13 +58,18 @@ type ByNews struct {
 }
 
 type ByCategory struct {
-	Category string   `json:"category"`
-	Articles []string `json:"articles"`
+	Category   string    `json:"category"`
+	Parent     string    `json:"parent,omitempty"`
+	Articles   []string  `json:"articles"`
+	Confidence []float64 `json:"confidence,omitempty"` // classifier score of each article with --confidence
 }
 
 type ByThread struct {
-	Title    string   `json:"title"`
-	Articles []string `json:"articles"`
+	Title          string   `json:"title"`
+	Articles       []string `json:"articles"`
+	AltTitles      []string `json:"alt_titles,omitempty"`      // next best titles with --alt-titles
+	Score          float64  `json:"score,omitempty"`           // importance of ranked thread
+	RelatedThreads []string `json:"related_threads,omitempty"` // first article names of other language threads with --cross-lang
 }
 
 type ByTop struct {
@@ -77,19 +87,30 @@ type Article struct {
 	Domain     string
 	SName      string
 	Text       string
+	Published  time.Time
+	Modified   time.Time
+	Authors    []string
+	Image      string
+	Section    string
+	Tags       []string
 	IsNews     bool
 	About      string
-	TFIDF      map[string]float64
+	TFIDF      Vector
 	CategoryId int
+	Scores     []float64 `json:"-"` // classifier score of each category
 	Words      string
+	Charset    string `json:",omitempty"` // source charset if file was transcoded to utf-8
+	content    []byte // content of archive entry, read from File if nil
+	cacheKey   string // cache key of article parsed in this run, empty for cached article
 }
 
 type Category struct {
-	ID       int
-	Name     string
-	LangCode string
-	Words    []string
-	Weights  map[string]float64
+	ID       int      `json:"id"`
+	Name     string   `json:"name,omitempty"`
+	LangCode string   `json:"lang_code"`
+	Docs     int      `json:"docs"`
+	Words    []string `json:"-"`
+	Weights  Vector   `json:"weights"`
 }
 
 //category – "society", "economy", "technology", "sports", "entertainment", "science" или "other"
@@ -109,7 +130,9 @@ func main() {
 	//println()
 	//println("-- tgnews --")
 	runtime.GOMAXPROCS(runtime.NumCPU())
-	args := os.Args
+	args := parseArgs(os.Args)
+	initTaxonomy()
+	initCache()
 	cmd := "languages"
 	dir := "data"
 	dirtrain := "train"
@@ -137,6 +160,24 @@ func main() {
 		toppairs(dir)
 	case "train":
 		train(dir, dirtrain)
+	case "build-model":
+		src := "train"
+		if len(args) >= 3 {
+			src = args[2]
+		}
+		checkErr(BuildModel(src, "nb", "logreg").Save(optString("model", modelFile)))
+	case "eval":
+		src := "train"
+		if len(args) >= 3 {
+			src = args[2]
+		}
+		evaluate(src)
+	case "server":
+		port := "8000"
+		if len(args) >= 3 {
+			port = args[2]
+		}
+		server(port)
 	}
 	t2 := time.Now()
 	dur := t2.Sub(t1)
@@ -146,53 +187,16 @@ func main() {
 	}
 }
 
-// AByInfo return parced articles
+// AByInfo return parced articles, only news articles with onlyNews
 func AByInfo(in []Article, onlyNews bool) (out []Article) {
-	var wg sync.WaitGroup
-	parser := func(a Article) {
-		defer wg.Done()
-		isNews := false
-		title, desc, url, sname, text, err := info(a.File)
-		if err != nil {
-			return
-		}
-		d, errDom := domain(url)
-		if errDom == nil {
-			a.Domain = d
-		}
-		if strings.Contains(url, "news/") {
-			isNews = true
-		}
-		if strings.Contains(sname, "news") {
-			isNews = true
-		}
-
-		if strings.Contains(d, "news") {
-			isNews = true
-		}
-
-		if !isNews && onlyNews {
-			return
-		}
-		a.Title = title
-		a.Desc = desc
-		a.Href = url
-		a.SName = sname
-		a.Text = text
-		a.IsNews = isNews
-		a.Words = strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		out = append(out, a)
+	if !onlyNews {
+		return in
 	}
-	i := 0
 	for _, a := range in {
-		wg.Add(1)
-		i++
-		go parser(a)
-		if i%500 == 0 {
-			wg.Wait()
+		if a.IsNews {
+			out = append(out, a)
 		}
 	}
-	wg.Wait()
 	return out
 }
 
@@ -243,66 +247,142 @@ func AByLang(dir string) []Article {
 	return articles
 }*/
 
-// AByLang isolate ru and en articles
+// AByLang return parsed ru and en articles sorted by file name,
+// each file is parsed once for language, news detection and words,
+// parsed articles are cached by file content with --cache. Files in windows-1251
+// and koi8-r are transcoded to utf-8, their count is printed.
+// Stages are walk, read, parse, detect language and tokenize, cached articles skip
+// detection. Cache keeps parsed fields without words, words are tokenized on each run
+// with current stop words
 func AByLang(dir string) []Article {
-	list, err := filePathWalkDir(dir)
-	if err != nil {
-		panic(err)
-	}
-	//println("files:", len(list))
-	articles := make([]Article, 0, 0)
-	/*
-		options := whatlanggo.Options{
-			Whitelist: map[whatlanggo.Lang]bool{
-				whatlanggo.Eng: true,
-				whatlanggo.Rus: true,
-			},
-		}
-	*/
-	//articles = append(articles, Article{Name: filepath.Base(f), File: f, LangCode: "en"})
-	//out := make(chan Article, 10)
-	var wg sync.WaitGroup
-	detector := func(f string) {
-		defer wg.Done()
-		title, desc, text, err := header(f)
-		if err != nil || title == "" {
-			return
-		}
-
-		info := whatlanggo.DetectLang(title + " " + desc + " " + text)
-		lang := info.String()
-		if lang == "Russian" {
-			if strings.ContainsAny(title+desc, "ії") {
-				lang = "ua"
+	var transcoded int64
+	read := func(a Article) (Article, bool) {
+		b, err := readArticle(a)
+		if err != nil {
+			println(err.Error())
+			return a, false
+		}
+		a.content = b
+		return a, true
+	}
+	parse := func(a Article) (Article, bool) {
+		b := a.content
+		a.content = nil
+		key := cache.Key(b)
+		if cached, ok := cache.Get(key); ok {
+			cached.Name, cached.File = a.Name, a.File
+			if cached.Charset != "" {
+				atomic.AddInt64(&transcoded, 1)
 			}
+			return cached, cached.Title != "" && cached.LangCode != ""
 		}
-		switch lang {
-		case "English":
-			articles = append(articles, Article{Name: filepath.Base(f), File: f, LangCode: "en"})
-			return
-		case "Russian":
-			articles = append(articles, Article{Name: filepath.Base(f), File: f, LangCode: "ru"})
-			return
-		default:
-
+		if b, a.Charset = toUTF8(b); a.Charset != "" {
+			atomic.AddInt64(&transcoded, 1)
 		}
-		return
+		m, err := infoReader(bytes.NewReader(b))
+		if err != nil {
+			return a, false
+		}
+		a.cacheKey = key
+		return fillArticle(a, m), true
 	}
-	cnt := 0
-	for _, f := range list {
-		cnt++
-		wg.Add(1)
-		go detector(f)
-		if cnt%500 == 0 {
-			wg.Wait()
+	detect := func(a Article) (Article, bool) {
+		if a.cacheKey == "" {
+			return a, true
+		}
+		a.LangCode = articleLang(a)
+		if err := cache.Put(a.cacheKey, a); err != nil {
+			println(err.Error())
 		}
+		return a, a.Title != "" && a.LangCode != ""
 	}
-	wg.Wait()
-	//println(len(articles))
-
+	tokenize := func(a Article) (Article, bool) {
+		a.Words = articleWords(a)
+		return a, true
+	}
+	articles := pipeline(walkArticles(dir), read, parse, detect, tokenize)
+	if transcoded > 0 {
+		println("transcoded to utf-8:", transcoded)
+	}
+	// archive entries come in archive order
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].File < articles[j].File
+	})
 	return articles
 }
 
+// parsedArticle fill article from parsed html, detect language and tokenize words
+func parsedArticle(a Article, m Meta) Article {
+	a = fillArticle(a, m)
+	a.LangCode = articleLang(a)
+	a.Words = articleWords(a)
+	return a
+}
+
+// articleLang return language of article title, description and start of text
+func articleLang(a Article) string {
+	text := a.Text
+	if len(text) > langTextLen {
+		text = text[:langTextLen]
+	}
+	return detectLang(a.Title, a.Desc, strings.ToValidUTF8(text, ""))
+}
+
+// fillArticle set parsed fields and news heuristic
+func fillArticle(a Article, m Meta) Article {
+	isNews := false
+	d, errDom := domain(m.URL)
+	if errDom == nil {
+		a.Domain = d
+	}
+	if strings.Contains(m.URL, "news/") {
+		isNews = true
+	}
+	if strings.Contains(m.SName, "news") {
+		isNews = true
+	}
+
+	if strings.Contains(d, "news") {
+		isNews = true
+	}
+	a.Title = m.Title
+	a.Desc = m.Desc
+	a.Href = m.URL
+	a.SName = m.SName
+	a.Text = m.Text
+	a.Published = m.Published
+	a.Modified = m.Modified
+	a.Authors = m.Authors
+	a.Image = m.Image
+	a.Section = m.Section
+	a.Tags = m.Tags
+	a.IsNews = isNews
+	return a
+}
+
+// articleWords return words of article title, description, text and site name
+func articleWords(a Article) string {
+	return strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName, a.LangCode), " ")
+}
+
+// detectLang return "en", "ru" or empty string for other languages
+func detectLang(title, desc, text string) string {
+	info := whatlanggo.DetectLang(title + " " + desc + " " + text)
+	lang := info.String()
+	if lang == "Russian" {
+		if strings.ContainsAny(title+desc, "ії") {
+			lang = "ua"
+		}
+	}
+	switch lang {
+	case "English":
+		return "en"
+	case "Russian":
+		return "ru"
+	}
+	return ""
+}
+
 func lang(dir string) {
 	articles := AByLang(dir)
 	//eng: 36440 rus: 31886
@@ -330,13 +410,6 @@ func lang(dir string) {
 	}
 	fmt.Println(string(json))
 	//bylang[0].LangCode = "en"
-
-	//pudge.DeleteFile("db/bylang")
-
-	//err = pudge.Set("db/bylang", dir, articles)
-	//if err != nil {
-	//	println(err.Error())
-	//}
 }
 
 //APrint print articles
@@ -386,13 +459,18 @@ func ARu(in []Article) (out []Article) {
 	return
 }
 
+// news print news articles, classified by news classifier if there are not news
+// train articles, otherwise articles with category
 func news(dir string) {
-	articles := make([]Article, 0, 0)
-	articles = categories(dir, false)
+	articles := categories(dir, false)
+	// categories are sorted by category, news follow file order
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].File < articles[j].File
+	})
 
 	byNews := &ByNews{}
 	for _, a := range articles {
-		if a.CategoryId == -1 {
+		if !a.IsNews {
 			continue
 		}
 		name := a.Name
@@ -410,14 +488,17 @@ func news(dir string) {
 }
 
 func traintf(in []Article) []Article {
-	tf := NewTFIDF()
+	if len(in) == 0 {
+		return in
+	}
+	tf := NewTFIDF(WithStopWords())
 	for _, a := range in {
 		//words := strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		tf.AddDocs(a.Words)
+		tf.AddLangDocs(a.LangCode, a.Words)
 	}
 	for i, a := range in {
 		//words := strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		w := tf.Cal(a.Words)
+		w := tf.CalLang(a.LangCode, a.Words)
 		//	println(a.Title)
 		in[i].About = top(w, 100)
 		in[i].TFIDF = w
@@ -456,34 +537,6 @@ func printmap(m map[string]float64) {
 	}
 }
 
-func header(file string) (title, desc, text string, err error) {
-	f, err := os.Open(file)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
-	}
-	defer f.Close()
-	b := make([]byte, 4096)
-	_, err = f.Read(b)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
-	}
-	doc, _ := goquery.NewDocumentFromReader(bytes.NewReader(b))
-	doc.Find("meta").Each(func(i int, s *goquery.Selection) {
-		op, _ := s.Attr("property")
-		con, _ := s.Attr("content")
-		if op == "og:title" {
-			title = con
-		}
-		if op == "og:description" {
-			desc = con
-		}
-	})
-	text = doc.Text()
-	return
-}
-
 func filePathWalkDir(root string) ([]string, error) {
 	var files []string
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
@@ -499,34 +552,17 @@ func filePathWalkDir(root string) ([]string, error) {
 	return files, err
 }
 
-func info(file string) (title, desc, url, name, text string, err error) {
-	f, err := os.Open(file)
+// infoReader parse meta and text from html
+func infoReader(r io.Reader) (m Meta, err error) {
+	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
-		fmt.Println(err.Error())
 		return
 	}
-	defer f.Close()
-	doc, _ := goquery.NewDocumentFromReader(f)
-	doc.Find("meta").Each(func(i int, s *goquery.Selection) {
-		op, _ := s.Attr("property")
-		con, _ := s.Attr("content")
-		if op == "og:title" {
-			title = con
-		}
-		if op == "og:description" {
-			desc = con
-		}
-		if op == "og:url" {
-			url = con
-		}
-		if op == "og:site_name" {
-			name = strings.ToLower(con)
-		}
-	})
-	space := regexp.MustCompile(`\s+`)
-	text = space.ReplaceAllString(doc.Text(), " ")
+	m = parseMeta(doc)
+	text := extractText(doc)
 	text = strings.Replace(text, ",", "", -1)
 	text = strings.Replace(text, ".", "", -1)
+	m.Text = text
 	return
 }
 
@@ -581,17 +617,24 @@ func words(text string) {
 //idf Таким образом, если «заяц» содержится в 1000 документах из 10 000 000 документов, то IDF будет равной: log(10 000 000/1000) = 4.
 //TF-IDF вес для слова «заяц» в выбранном документе будет равен: 0,03 × 4 = 0,12
 
-func bigwords(text string) (res []string) {
+// bigwords return lowercased words longer than 3 letters, words are kept whole
+// if language has stemming tokenizer, otherwise cut to 6 or 8 letters.
+// Stop words of language are skipped
+func bigwords(text, lang string) (res []string) {
+	_, stemmed := tokenizers[lang]
+	stopWords := stopWordsFor(lang)
 	arr := strings.Fields(text)
 	for _, w := range arr {
 		if len([]rune(w)) < 4 {
 			continue
 		}
-		if len([]rune(w)) > 8 {
-			w = string([]rune(w)[:8])
-		} else {
-			if len([]rune(w)) > 6 {
-				w = string([]rune(w)[:6])
+		if !stemmed {
+			if len([]rune(w)) > 8 {
+				w = string([]rune(w)[:8])
+			} else {
+				if len([]rune(w)) > 6 {
+					w = string([]rune(w)[:6])
+				}
 			}
 		}
 		if strings.HasPrefix(w, "<") {
@@ -605,66 +648,45 @@ func bigwords(text string) (res []string) {
 			w = strings.ReplaceAll(w, "»", "")
 			w = strings.ReplaceAll(w, ",", "")
 		}
-		res = append(res, strings.ToLower(w))
+		w = strings.ToLower(w)
+		if _, ok := stopWords[w]; ok {
+			continue
+		}
+		res = append(res, w)
 	}
 	return
 }
 
-func top(m map[string]float64, limit int) string {
-	res := make([]string, 0)
-	n := map[float64][]string{}
-	var a []float64
-	for k, v := range m {
-		n[v] = append(n[v], k)
-	}
-	for k := range n {
-		a = append(a, k)
+// top return terms with max weights
+func top(v Vector, limit int) string {
+	idx := make([]int, len(v.IDs))
+	for i := range idx {
+		idx[i] = i
 	}
-	sort.Sort(sort.Reverse(sort.Float64Slice(a)))
-	j := 0
-	for _, k := range a {
-		if k == 1 {
-			break
-		}
-		for _, s := range n[k] {
-			j++
-			res = append(res, s)
-			//fmt.Printf("%d %s, %.20f\n", j, s, k)
-			if j > limit {
-				break
-			}
+	sort.SliceStable(idx, func(i, j int) bool {
+		if v.Weights[idx[i]] == v.Weights[idx[j]] {
+			return vocab.Term(v.IDs[idx[i]]) < vocab.Term(v.IDs[idx[j]])
 		}
-		if j > limit {
+		return v.Weights[idx[i]] > v.Weights[idx[j]]
+	})
+	res := make([]string, 0, limit)
+	for j, i := range idx {
+		if j >= limit || v.Weights[i] == 1 {
 			break
 		}
+		res = append(res, vocab.Term(v.IDs[i]))
 	}
 	return strings.Join(res, " ")
 }
 
-func makeCorpus(a []string) map[string]int {
-	retVal := make(map[string]int)
-	var id int
+// makeCorpus intern terms of documents to vocabulary ids
+func makeCorpus(a []string) *Vocab {
 	for _, s := range a {
 		for _, f := range strings.Fields(s) {
-			if _, ok := retVal[f]; !ok {
-				retVal[f] = id
-				id++
-			}
+			vocab.ID(f)
 		}
 	}
-	return retVal
-}
-
-func categWords(dir string) []string {
-	articles := make([]Article, 0, 0)
-	articles = AByLang(dir)
-	articles = AByInfo(articles, false)
-	//APrint(ARu(articles))
-	res := make([]string, 0)
-	for _, a := range articles {
-		res = append(res, bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName)...)
-	}
-	return res
+	return vocab
 }
 
 func train(dir, dirtrain string) {
@@ -674,12 +696,8 @@ func train(dir, dirtrain string) {
 	//t2 := time.Now()
 
 	//cosine
-	tf := NewTFIDF()
-	for _, a := range articles {
-		//words := strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		tf.AddDocs(a.Words)
-	}
-	categs := initCategs(tf)
+	m := loadModel()
+	tf, categs := m.TFIDF(), m.Categories
 	//cosine
 	var input string
 	all := len(articles)
@@ -701,13 +719,13 @@ func train(dir, dirtrain string) {
 		println()
 
 		//words := strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		w := tf.Cal(a.Words)
+		w := tf.CalLang(a.LangCode, a.Words)
 		maxsim := float64(0)
 		maxj := -1
 
 		for j := range categs {
 			sim := Cosine(w, categs[j].Weights)
-			if sim > float64(0.555) && sim > maxsim {
+			if sim > m.Threshold && sim > maxsim {
 				maxsim = sim
 				maxj = j
 			}
@@ -719,129 +737,121 @@ func train(dir, dirtrain string) {
 		}
 
 		fmt.Print(i, all, ": Enter text: \n")
-		println(`
-			// 0. Stop
-			// 1. Society (включает Politics, Elections, Legislation, Incidents, Crime)
-			// 2. Economy (включает Markets, Finance, Business)
-			// 3. Technology (включает Gadgets, Auto, Apps, Internet services)
-			// 4. Sports (включает E-Sports)
-			// 5. Entertainment (включает Movies, Music, Games, Books, Arts)
-			// 6. Science (включает Health, Biology, Physics, Genetics)
-			// 7. Other (новостные статьи, не попавшие в перечисленные выше категории)
-			// 8. Not news
-			// 9. Skip
-			`)
+		println("\t// " + stopAnswer + ". Stop")
+		for _, t := range taxonomy {
+			println("\t// " + t.Folder + ". " + t.Name)
+		}
+		println("\t// " + notNewsFolder + ". Not news\n\t// " + skipFolder + ". Skip")
 		fmt.Scanln(&input)
 		fmt.Print(input)
-		if input == "0" {
+		if input == stopAnswer {
 			break
 		}
-		if input == "9" {
+		if input == skipFolder {
 			continue
 		}
 		if input == "" {
-			input = "9"
+			input = skipFolder
 			continue
 		}
-		s := fmt.Sprintf("train/%s/%s/%s", a.LangCode, input, a.Name)
-		copy(a.File, s)
+		s := fmt.Sprintf("train/%s/%s/%s", a.LangCode, input, filepath.Base(a.Name))
+		content, err := readArticle(a)
+		checkErr(err)
+		// folder of new category is created on first article
+		checkErr(os.MkdirAll(filepath.Dir(s), 0755))
+		checkErr(ioutil.WriteFile(s, content, 0644))
 	}
 	println(cnt)
 }
 
-func initCategs(tf *TFIDF) (categs []Category) {
-
+// trainArticles return parsed articles from dir/<lang>/<id> folders,
+// folder language is article language and taxon with folder is category.
+// Missing folder is category without articles
+func trainArticles(dir string) (res []Article) {
 	langs := []string{"en", "ru"}
 	for _, l := range langs {
-		for i := 1; i < 8; i++ {
-			files := fmt.Sprintf("train/%s/%d", l, i)
-			categ := Category{ID: i, LangCode: l}
-			categ.LangCode = l
+		for i, t := range taxonomy {
+			folder := fmt.Sprintf("%s/%s/%s", dir, l, t.Folder)
+			if !hasArticles(folder) {
+				continue
+			}
+			for _, a := range folderArticles(folder, l) {
+				a.CategoryId = i
+				res = append(res, a)
+			}
+		}
+	}
+	return
+}
+
+// folderArticles return parsed articles of folder with language,
+// words of articles detected as other language are tokenized again
+func folderArticles(dir, lang string) []Article {
+	articles := AByLang(dir)
+	for i, a := range articles {
+		if a.LangCode != lang {
+			articles[i].LangCode = lang
+			articles[i].Words = articleWords(articles[i])
+		}
+	}
+	return articles
+}
 
-			categ.Words = categWords(files)
-			//println(i, len(categ.Words))
+// categsOf return categories with words of labeled articles
+func categsOf(articles []Article) (categs []Category) {
+	langs := []string{"en", "ru"}
+	for _, l := range langs {
+		for i, t := range taxonomy {
+			categ := Category{ID: t.ID, Name: t.Name, LangCode: l}
+			for _, a := range articles {
+				if a.LangCode == l && a.CategoryId == i {
+					categ.Words = append(categ.Words, strings.Fields(a.Words)...)
+					categ.Docs++
+				}
+			}
 			categs = append(categs, categ)
-			tf.AddDocs(strings.Join(categ.Words, " "))
 		}
 	}
+	return
+}
+
+// centroids add category words to tf and calculate category weights
+func centroids(tf *TFIDF, categs []Category) []Category {
+	for _, categ := range categs {
+		tf.AddLangDocs(categ.LangCode, strings.Join(categ.Words, " "))
+	}
 	for i := range categs {
-		categs[i].Weights = tf.Cal(strings.Join(categs[i].Words, " "))
+		categs[i].Weights = tf.CalLang(categs[i].LangCode, strings.Join(categs[i].Words, " "))
 	}
-	return
+	return categs
 }
 
 func categories(dir string, print bool) []Article {
-	//articles := make([]Article, 0, 0)
 	//t1 := time.Now()
-	//err := pudge.Get("db/bylang", dir, &articles)
-	//if err != nil || len(articles) == 0 {
-
 	articles := AByLang(dir)
 	//println(len(articles))
-	//}
 
 	articles = AByInfo(articles, false)
 	//t2 := time.Now()
 
-	//cosine
-	tf := NewTFIDF()
-	for _, a := range articles {
-		//words := strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		tf.AddDocs(a.Words)
-	}
-	categs := initCategs(tf)
-	//cosine
+	m := loadModel()
+	clf, newsClf := m.Classifier(), m.NewsClassifier()
 
 	cnt := 0
 	for i, a := range articles {
-		//words := strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		w := tf.Cal(a.Words)
-		_ = w
-		maxsim := float64(0)
-		maxj := -1
 		articles[i].CategoryId = -1
-
-		N := len(categs)
-		sem := make(chan bool, N)
-		res := make(map[int]float64)
-		var mu sync.Mutex
-		for j, _ := range categs {
-			if categs[j].LangCode != a.LangCode {
-				sem <- true
+		if newsClf != nil {
+			// not news articles are not categorized
+			if articles[i].IsNews, _ = newsClf.Classify(a); !articles[i].IsNews {
 				continue
 			}
-			go func(a, b map[string]float64, c int) {
-				sim := Cosine(a, b)
-				mu.Lock()
-				res[c] = sim
-				mu.Unlock()
-				sem <- true
-			}(w, categs[j].Weights, j)
-			/*sim := Cosine(w, categs[j].Weights)
-			if sim > float64(0.555) && sim > maxsim {
-				maxsim = sim
-				maxj = j
-			}*/
 		}
-		for i := 0; i < N; i++ {
-			<-sem
+		articles[i].CategoryId, articles[i].Scores = clf.Classify(a)
+		if newsClf == nil {
+			articles[i].IsNews = articles[i].CategoryId != -1
 		}
-		for i := 0; i < N; i++ {
-			if res[i] > float64(0.555) && res[i] > maxsim {
-				maxsim = res[i]
-				maxj = i
-			}
-		}
-		if maxj >= 0 {
-			if maxj > 6 {
-				articles[i].CategoryId = maxj - 7
-			} else {
-				articles[i].CategoryId = maxj
-			}
-
-			//println("Current:", categs[maxj].ID, categs[maxj].LangCode)
+		if articles[i].CategoryId >= 0 {
 			cnt++
-			continue
 		}
 	}
 	//t3 := time.Now()
@@ -849,34 +859,15 @@ func categories(dir string, print bool) []Article {
 		//fmt.Printf("The %v took %v to run.  \n", t2.Sub(t1), t3.Sub(t2))
 	}
 
-	sort.Slice(articles, func(i, j int) bool {
+	sort.SliceStable(articles, func(i, j int) bool {
 		return articles[i].CategoryId < articles[j].CategoryId
 	})
 	if print {
-		//err = pudge.Set("db/bycateg", dir, articles)
-		//if err != nil {
-		//	fmt.Println(err.Error())
-		//}
-
-		byCategs := make([]ByCategory, 7)
-		for i := 0; i < 7; i++ {
+		byCategs := make([]ByCategory, len(taxonomy))
+		for i := range taxonomy {
 			byCateg := ByCategory{}
-			switch i {
-			case 0:
-				byCateg.Category = "society"
-			case 1:
-				byCateg.Category = "economy"
-			case 2:
-				byCateg.Category = "technology"
-			case 3:
-				byCateg.Category = "sports"
-			case 4:
-				byCateg.Category = "entertainment"
-			case 5:
-				byCateg.Category = "science"
-			case 6:
-				byCateg.Category = "other"
-			}
+			byCateg.Category = categName(i)
+			byCateg.Parent = categParent(i)
 			byCateg.Articles = make([]string, 0)
 			byCategs[i] = byCateg
 		}
@@ -889,6 +880,9 @@ func categories(dir string, print bool) []Article {
 				name = a.Title
 			}
 			byCategs[a.CategoryId].Articles = append(byCategs[a.CategoryId].Articles, name)
+			if optBool("confidence", false) {
+				byCategs[a.CategoryId].Confidence = append(byCategs[a.CategoryId].Confidence, a.Scores[a.CategoryId])
+			}
 		}
 		b, err := json.MarshalIndent(byCategs, "", "  ")
 		if err != nil {
@@ -901,95 +895,48 @@ func categories(dir string, print bool) []Article {
 }
 
 //Cosine return cosine similarity
-func Cosine(a, b map[string]float64) (sim float64) {
-	vec1, vec2 := vector(a, b)
-
-	var product, squareSumA, squareSumB float64
-	/*
-		N := len(vec1)
-		sem := make(chan bool, N)
-		for i, v := range vec1 {
-			go func(a, b float64) {
-				product += a * b
-				squareSumA += a * a
-				squareSumB += b * b
-				sem <- true
-			}(v, vec2[i])
-		}
-		for i := 0; i < N; i++ {
-			<-sem
-		}*/
-
-	for i, v := range vec1 {
-
-		product += v * vec2[i]
-		squareSumA += v * v
-		squareSumB += vec2[i] * vec2[i]
-	}
-
-	if squareSumA == 0 || squareSumB == 0 {
+func Cosine(a, b Vector) (sim float64) {
+	if a.Norm == 0 || b.Norm == 0 {
 		return 0
 	}
-
-	return normalize(product / (math.Sqrt(squareSumA) * math.Sqrt(squareSumB)))
+	return normalize(a.Dot(b) / (a.Norm * b.Norm))
 }
 
 func normalize(cos float64) float64 {
 	return 0.5 + 0.5*cos
 }
 
-func vector(a, b map[string]float64) ([]float64, []float64) {
-	terms := make(map[string]interface{})
-	for term := range a {
-		terms[term] = nil
-	}
-	for term := range b {
-		terms[term] = nil
-	}
-	lenterm := len(terms)
-
-	vec1 := make([]float64, 0, lenterm)
-	vec2 := make([]float64, 0, lenterm)
-	for term := range terms {
-		vec1 = append(vec1, a[term])
-		vec2 = append(vec2, b[term])
+func threads(dir string, print bool) [][]Article {
+	articles := categories(dir, false)
+	if period := optInt("period", 0); period > 0 {
+		articles = publishedIn(articles, time.Duration(period)*time.Second)
 	}
 
-	return vec1, vec2
-}
+	allpairs := chunkPairs(articles)
+	if print {
 
-/*
-func vector(a, b map[string]float64) ([]float64, []float64) {
-	terms := make(map[string]interface{})
-	for term := range a {
-		terms[term] = nil
-	}
-	for term := range b {
-		terms[term] = nil
-	}
-	lenterm := len(terms)
+		byThreads := make([]ByThread, 0)
+		related := relatedThreads(allpairs)
+		for _, p := range allpairs {
+			byThread := threadOf(p)
+			byThread.RelatedThreads = related[p[0].File]
+			byThreads = append(byThreads, byThread)
+		}
+		b, err := json.MarshalIndent(byThreads, "", "  ")
+		if err != nil {
+			fmt.Println(err.Error())
+		}
 
-	vec1 := make([]float64, lenterm)
-	vec2 := make([]float64, lenterm)
-	i := 0
-	for term := range terms {
-		vec1[i] = a[term]
-		vec2[i] = a[term]
-		//vec2 = append(vec2, b[term])
-		i++
+		fmt.Println(string(b))
 	}
-
-	return vec1,vec2
+	return allpairs
+	//fmt.Printf("pairs:%+v\n", allpairs)
+	//APrint(tra)
 }
-*/
-func threads(dir string, print bool) [][]Article {
-	articles := make([]Article, 0, 0)
-
-	//err := pudge.Get("db/bycateg", dir, &articles)
-	//if err != nil || len(articles) == 0 {
-	articles = categories(dir, false)
-	//}
 
+// chunkPairs group news articles by language and category and find threads in each group,
+// threads of different languages are linked by relatedThreads
+func chunkPairs(articles []Article) [][]Article {
 	chunks := make(map[string][]Article)
 	for _, a := range articles {
 		if a.CategoryId == -1 {
@@ -1002,47 +949,160 @@ func threads(dir string, print bool) [][]Article {
 		chunks[key] = append(chunks[key], a)
 	}
 
+	keys := make([]string, 0, len(chunks))
+	for key := range chunks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 	allpairs := make([][]Article, 0)
-	for _, val := range chunks {
-		arr := pairs(val)
+	for _, key := range keys {
+		arr := pairs(chunks[key])
 		for _, p := range arr {
 			allpairs = append(allpairs, p)
 		}
 	}
-	if print {
+	return allpairs
+}
 
-		byThreads := make([]ByThread, 0)
-		for _, p := range allpairs {
-			byThread := ByThread{}
-			byThread.Articles = make([]string, 0)
-			if len(p) > 0 {
-				byThread.Title = p[0].Title
+// threadOf return thread output for sorted pair
+func threadOf(p []Article) ByThread {
+	byThread := ByThread{}
+	byThread.Articles = make([]string, 0)
+	if titles := threadTitles(p); len(titles) > 0 {
+		byThread.Title = titles[0]
+		if n := optInt("alt-titles", 0); n > 0 {
+			byThread.AltTitles = titles[1:]
+			if len(byThread.AltTitles) > n {
+				byThread.AltTitles = byThread.AltTitles[:n]
 			}
-			for _, it := range p {
-				name := it.Name
-				if isDebug {
-					name = it.Title
+		}
+	}
+	for _, it := range p {
+		name := it.Name
+		if isDebug {
+			name = it.Title
+		}
+		byThread.Articles = append(byThread.Articles, name)
+	}
+	return byThread
+}
+
+// pairs group similar articles to threads, articles in thread are sorted by similarity with thread.
+// --linkage=average|complete|single selects agglomerative clustering with --cutoff distance,
+// --linkage=greedy joins all unassigned neighbors of article.
+// Articles without neighbors are one article threads with --singletons
+func pairs(in []Article) (sortedpairs [][]Article) {
+	// clustering does not depend on input order
+	sort.SliceStable(in, func(i, j int) bool {
+		return in[i].Name < in[j].Name
+	})
+	trained := traintf(in)
+	tres := float64(0.777)
+	// more index terms and less shared terms give better recall, but slower
+	index := NewTermIndex(trained, optInt("index-terms", 30))
+	minShared := optInt("min-shared", 2)
+	allpairs := make([][]Article, 0)
+	window := time.Duration(optInt("window", 2*24*3600)) * time.Second
+	if linkage, ok := parseLinkage(optString("linkage", "average")); ok {
+		sims := make(map[int]map[int]float64)
+		for i := range trained {
+			for _, j := range index.Candidates(i, minShared) {
+				if j <= i || tooFar(trained[i], trained[j], window) {
+					continue
+				}
+				if sims[i] == nil {
+					sims[i] = make(map[int]float64)
 				}
-				byThread.Articles = append(byThread.Articles, name)
+				sims[i][j] = Cosine(trained[i].TFIDF, trained[j].TFIDF)
 			}
-			byThreads = append(byThreads, byThread)
 		}
-		b, err := json.MarshalIndent(byThreads, "", "  ")
-		if err != nil {
-			fmt.Println(err.Error())
+		cannotLink := func(i, j int) bool {
+			return tooFar(trained[i], trained[j], window)
+		}
+		for _, cluster := range Cluster(len(trained), sims, linkage, optFloat("cutoff", 1-tres), cannotLink) {
+			pair := make([]Article, 0, len(cluster))
+			for _, i := range cluster {
+				pair = append(pair, trained[i])
+			}
+			allpairs = append(allpairs, pair)
 		}
+	} else {
+		allpairs = greedyPairs(trained, index, minShared, tres, window)
+	}
+	if optBool("singletons", false) {
+		allpairs = append(allpairs, singletons(trained, allpairs)...)
+	}
+	for _, pair := range allpairs {
+		sortedpairs = append(sortedpairs, sortThread(pair))
+	}
+	return
+}
 
-		fmt.Println(string(b))
+// tooFar return true if both articles have published time and it differs more than window,
+// zero window links articles of any time
+func tooFar(a, b Article, window time.Duration) bool {
+	if window <= 0 || a.Published.IsZero() || b.Published.IsZero() {
+		return false
 	}
-	return allpairs
-	//fmt.Printf("pairs:%+v\n", allpairs)
-	//APrint(tra)
+	d := a.Published.Sub(b.Published)
+	return d > window || -d > window
 }
 
-func pairs(in []Article) (sortedpairs [][]Article) {
+// anyTooFar return true if article is too far from any article of thread
+func anyTooFar(thread []Article, a Article, window time.Duration) bool {
+	for _, b := range thread {
+		if tooFar(a, b, window) {
+			return true
+		}
+	}
+	return false
+}
 
-	trained := traintf(in)
-	tres := float64(0.777)
+// publishedIn return articles published in period before the latest published article,
+// number of skipped articles without published time is printed
+func publishedIn(articles []Article, period time.Duration) []Article {
+	var latest time.Time
+	for _, a := range articles {
+		if a.Published.After(latest) {
+			latest = a.Published
+		}
+	}
+	res := make([]Article, 0, len(articles))
+	untimed := 0
+	for _, a := range articles {
+		if a.Published.IsZero() {
+			untimed++
+			continue
+		}
+		if latest.Sub(a.Published) <= period {
+			res = append(res, a)
+		}
+	}
+	if untimed > 0 {
+		println("period: skipped", untimed, "articles without published time")
+	}
+	return res
+}
+
+// singletons return one article threads for news articles not in threads
+func singletons(articles []Article, threads [][]Article) [][]Article {
+	inThread := make(map[string]bool)
+	for _, t := range threads {
+		for _, a := range t {
+			inThread[a.File] = true
+		}
+	}
+	res := make([][]Article, 0)
+	for _, a := range articles {
+		if a.IsNews && !inThread[a.File] {
+			res = append(res, []Article{a})
+		}
+	}
+	return res
+}
+
+// greedyPairs join first unassigned article with all unassigned articles more similar than tres
+func greedyPairs(trained []Article, index *TermIndex, minShared int, tres float64, window time.Duration) [][]Article {
 	var cur Article
 	skiplist := make(map[int]bool)
 	allpairs := make([][]Article, 0)
@@ -1052,11 +1112,8 @@ func pairs(in []Article) (sortedpairs [][]Article) {
 			continue
 		}
 		pairs := make([]Article, 0)
-		for j := 0; j < len(trained); j++ {
-			if i == j {
-				continue
-			}
-			if _, ok := skiplist[j]; ok {
+		for _, j := range index.Candidates(i, minShared) {
+			if _, ok := skiplist[j]; ok || tooFar(cur, trained[j], window) || anyTooFar(pairs, trained[j], window) {
 				continue
 			}
 			sim := Cosine(cur.TFIDF, trained[j].TFIDF)
@@ -1073,83 +1130,83 @@ func pairs(in []Article) (sortedpairs [][]Article) {
 			allpairs = append(allpairs, pairs)
 		}
 	}
+	return allpairs
+}
+
+// sortThread sort articles by similarity with all thread words
+func sortThread(pair []Article) []Article {
 	type forsort struct {
 		Article Article
 		Sim     float64
 	}
-	for _, pair := range allpairs {
-		tf := NewTFIDF()
-		allwords := make([]string, 0)
-		for _, a := range pair {
-			words := bigwords(a.Title + " " + a.Desc + " " + a.Text + " " + a.SName)
-			tf.AddDocs(strings.Join(words, " "))
-			allwords = append(allwords, words...)
-		}
-		tf.AddDocs(strings.Join(allwords, " "))
-		allw := tf.Cal(strings.Join(allwords, " "))
-		forsorts := make([]forsort, 0)
-		for _, a := range pair {
-
-			//words := bigwords(a.Title + " " + a.Desc + " " + a.Text + " " + a.SName)
-			curw := tf.Cal(a.Words) //strings.Join(words, " "))
-			sim := Cosine(allw, curw)
-			forsorts = append(forsorts, forsort{Article: a, Sim: sim})
-			//fmt.Printf("%s %s %.5f\n\n", a.Title, a.File, sim)
-		}
-		sort.Slice(forsorts, func(i, j int) bool {
-			return forsorts[i].Sim > forsorts[j].Sim
-		})
-		sortedpair := make([]Article, 0)
-		for _, f := range forsorts {
-			//fmt.Printf("%s  %.5f\n\n", f.Article.Title, f.Sim)
-			sortedpair = append(sortedpair, f.Article)
-		}
-		sortedpairs = append(sortedpairs, sortedpair)
+	lang := pair[0].LangCode
+	tf := NewTFIDF(WithStopWords())
+	allwords := make([]string, 0)
+	for _, a := range pair {
+		words := strings.Fields(a.Words)
+		tf.AddLangDocs(lang, strings.Join(words, " "))
+		allwords = append(allwords, words...)
+	}
+	tf.AddLangDocs(lang, strings.Join(allwords, " "))
+	allw := tf.CalLang(lang, strings.Join(allwords, " "))
+	forsorts := make([]forsort, 0)
+	for _, a := range pair {
+
+		//words := bigwords(a.Title + " " + a.Desc + " " + a.Text + " " + a.SName)
+		curw := tf.CalLang(lang, a.Words) //strings.Join(words, " "))
+		sim := Cosine(allw, curw)
+		forsorts = append(forsorts, forsort{Article: a, Sim: sim})
+		//fmt.Printf("%s %s %.5f\n\n", a.Title, a.File, sim)
+	}
+	sort.SliceStable(forsorts, func(i, j int) bool {
+		return forsorts[i].Sim > forsorts[j].Sim
+	})
+	sortedpair := make([]Article, 0)
+	for _, f := range forsorts {
+		//fmt.Printf("%s  %.5f\n\n", f.Article.Title, f.Sim)
+		sortedpair = append(sortedpair, f.Article)
 	}
-
-	return
+	return sortedpair
 }
 
-func toppairs(dir string) {
-	allpairs := threads(dir, false)
-	type topPair struct {
-		Article Article
-		Sim     float64
-		Pair    []Article
-		CategID int
-	}
-	tf := NewTFIDF()
+type topPair struct {
+	Article Article
+	Score   float64
+	Pair    []Article
+	CategID int
+}
 
+// rankPairs sort threads by importance
+func rankPairs(allpairs [][]Article, imp *Importance) []topPair {
+	latest := latestTime(allpairs)
 	tops := make([]topPair, 0)
 	for _, p := range allpairs {
 		top := topPair{Pair: p}
 		if len(p) > 0 {
 			top.Article = p[0]
 			top.CategID = p[0].CategoryId
+			top.Score = imp.Score(p, latest)
 		}
-		//a := p[0]
-		//words := strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		//_ = words
-		//tf.AddDocs(words)
 		tops = append(tops, top)
 	}
-	categs := initCategs(tf)
-	//fmt.Printf("%d\n", len(categs))
-	for i, t := range tops {
-		//println(t.Article.LangCode, t.CategID, t.Article.Title)
-		idx := t.CategID
-		if t.Article.LangCode == "ru" {
-			idx += 7
-		}
-		a := t.Article
-		//words := strings.Join(bigwords(a.Title+" "+a.Desc+" "+a.Text+" "+a.SName), " ")
-		w := tf.Cal(a.Words)
-		sim := Cosine(categs[idx].Weights, w)
-		tops[i].Sim = sim
-	}
-	sort.Slice(tops, func(i, j int) bool {
-		return tops[i].Sim > tops[j].Sim
+	sort.SliceStable(tops, func(i, j int) bool {
+		return tops[i].Score > tops[j].Score
 	})
+	return tops
+}
+
+// threadOfTop return thread with importance score and related threads
+func threadOfTop(t topPair, related map[string][]string) ByThread {
+	byThread := threadOf(t.Pair)
+	byThread.Score = t.Score
+	byThread.RelatedThreads = related[t.Article.File]
+	return byThread
+}
+
+func toppairs(dir string) {
+	allpairs := threads(dir, false)
+	tops := rankPairs(allpairs, NewImportance())
+	related := relatedThreads(allpairs)
 	bytops := make([]ByTop, 0)
 	bytop := ByTop{}
 	bytop.Category = "any"
@@ -1158,18 +1215,12 @@ func toppairs(dir string) {
 		if i > 9 {
 			break
 		}
-		byThread := ByThread{}
-		for _, a := range t.Pair {
-			byThread.Articles = append(byThread.Articles, a.Name)
-		}
-
-		byThread.Title = t.Article.Title
-		bytop.Threads = append(bytop.Threads, byThread)
+		bytop.Threads = append(bytop.Threads, threadOfTop(t, related))
 		//fmt.Printf("%s %0.5f\n\n", t.Article.Title, t.Sim)
 	}
 	bytops = append(bytops, bytop)
 
-	sort.Slice(tops, func(i, j int) bool {
+	sort.SliceStable(tops, func(i, j int) bool {
 		return tops[i].CategID < tops[j].CategID
 	})
 
@@ -1182,31 +1233,10 @@ func toppairs(dir string) {
 			}
 			lastcateg = t.CategID
 			bytop = ByTop{}
-			switch lastcateg {
-			case 0:
-				bytop.Category = "society"
-			case 1:
-				bytop.Category = "economy"
-			case 2:
-				bytop.Category = "technology"
-			case 3:
-				bytop.Category = "sports"
-			case 4:
-				bytop.Category = "entertainment"
-			case 5:
-				bytop.Category = "science"
-			case 6:
-				bytop.Category = "other"
-			}
+			bytop.Category = categName(lastcateg)
 			bytop.Threads = make([]ByThread, 0)
 		}
-		byThread := ByThread{}
-		for _, a := range t.Pair {
-			byThread.Articles = append(byThread.Articles, a.Name)
-		}
-
-		byThread.Title = t.Article.Title
-		bytop.Threads = append(bytop.Threads, byThread)
+		bytop.Threads = append(bytop.Threads, threadOfTop(t, related))
 		//fmt.Printf("%s %0.5f\n\n", t.Article.Title, t.Sim)
 	}
 	bytops = append(bytops, bytop)
@@ -1220,14 +1250,18 @@ func toppairs(dir string) {
 }
 
 // New new model with default
-func NewTFIDF() *TFIDF {
-	return &TFIDF{
+func NewTFIDF(options ...TFIDFOption) *TFIDF {
+	f := &TFIDF{
 		docIndex:  make(map[string]int),
 		termFreqs: make([]map[string]int, 0),
 		termDocs:  make(map[string]int),
 		n:         0,
 		tokenizer: &EnTokenizer{},
 	}
+	for _, option := range options {
+		option(f)
+	}
+	return f
 }
 
 // NewTokenizer new with specified tokenizer
@@ -1243,13 +1277,18 @@ func NewTokenizer(tokenizer Tokenizer) *TFIDF {
 
 // AddDocs add train documents
 func (f *TFIDF) AddDocs(docs ...string) {
+	f.AddLangDocs("", docs...)
+}
+
+// AddLangDocs add train documents tokenized with tokenizer for language
+func (f *TFIDF) AddLangDocs(lang string, docs ...string) {
 	for _, doc := range docs {
-		h := hash(doc)
+		h := langHash(lang, doc)
 		if f.docHashPos(h) >= 0 {
 			return
 		}
 
-		termFreq := f.termFreq(doc)
+		termFreq := f.termFreq(lang, doc)
 		if len(termFreq) == 0 {
 			return
 		}
@@ -1266,14 +1305,17 @@ func (f *TFIDF) AddDocs(docs ...string) {
 }
 
 // Cal calculate tf-idf weight for specified document
-func (f *TFIDF) Cal(doc string) (weight map[string]float64) {
-	weight = make(map[string]float64)
+func (f *TFIDF) Cal(doc string) Vector {
+	return f.CalLang("", doc)
+}
 
+// CalLang calculate tf-idf weight vector for document in language
+func (f *TFIDF) CalLang(lang, doc string) Vector {
 	var termFreq map[string]int
 
-	docPos := f.docPos(doc)
+	docPos := f.docHashPos(langHash(lang, doc))
 	if docPos < 0 {
-		termFreq = f.termFreq(doc)
+		termFreq = f.termFreq(lang, doc)
 	} else {
 		termFreq = f.termFreqs[docPos]
 	}
@@ -1282,25 +1324,38 @@ func (f *TFIDF) Cal(doc string) (weight map[string]float64) {
 	for _, freq := range termFreq {
 		docTerms += freq
 	}
-	for term, freq := range termFreq {
-		weight[term] = tfidf(freq, docTerms, f.termDocs[term], f.n)
+	// sorted terms get the same ids in every run
+	terms := make([]string, 0, len(termFreq))
+	for term := range termFreq {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	ids := make([]int32, 0, len(termFreq))
+	weights := make([]float64, 0, len(termFreq))
+	for _, term := range terms {
+		ids = append(ids, vocab.ID(term))
+		weights = append(weights, tfidf(termFreq[term], docTerms, f.termDocs[term], f.n))
 	}
 
-	return weight
+	return newVector(ids, weights)
 }
 
-func (f *TFIDF) termFreq(doc string) (m map[string]int) {
+func (f *TFIDF) termFreq(lang, doc string) (m map[string]int) {
 	m = make(map[string]int)
 
-	tokens := f.tokenizer.Seg(doc)
+	tokenizer := f.tokenizer
+	if lang != "" {
+		tokenizer = tokenizerFor(lang)
+	}
+	tokens := tokenizer.Seg(doc)
 	if len(tokens) == 0 {
 		return
 	}
 
 	for _, term := range tokens {
-		//if _, ok := f.stopWords[term]; ok {
-		//	continue
-		//}
+		if _, ok := f.stopWords[term]; ok {
+			continue
+		}
 
 		m[term]++
 	}
@@ -1320,6 +1375,14 @@ func (f *TFIDF) docPos(doc string) int {
 	return f.docHashPos(hash(doc))
 }
 
+// langHash return document hash, language is part of key
+func langHash(lang, doc string) string {
+	if lang == "" {
+		return hash(doc)
+	}
+	return hash(lang + "\x00" + doc)
+}
+
 func hash(text string) string {
 	h := md5.New()
 	h.Write([]byte(text))
@@ -1332,6 +1395,25 @@ func tfidf(termFreq, docTerms, termDocs, N int) float64 {
 	return tf * idf
 }
 
+// tokenizers stemming tokenizers by article language code
+var tokenizers = map[string]Tokenizer{
+	"en": &Porter2Tokenizer{},
+	"ru": &RuTokenizer{},
+}
+
+// RegisterTokenizer set tokenizer for language code
+func RegisterTokenizer(lang string, tokenizer Tokenizer) {
+	tokenizers[lang] = tokenizer
+}
+
+// tokenizerFor return tokenizer for language, space tokenizer if not registered
+func tokenizerFor(lang string) Tokenizer {
+	if tokenizer, ok := tokenizers[lang]; ok {
+		return tokenizer
+	}
+	return &EnTokenizer{}
+}
+
 func (s *EnTokenizer) Seg(text string) []string {
 	return strings.Fields(text)
 }
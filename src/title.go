@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	titleKeyTerms  = 10  // top terms of thread for overlap with title
+	titleMaxLen    = 100 // longer titles are penalized
+	titleClickbait = 0.3 // penalty for each clickbait marker
+	titleAllCaps   = 0.5
+	titleSiteName  = 0.3
+)
+
+var (
+	clickbait = regexp.MustCompile(`(?i)(you won'?t believe|shocking|this is why|here'?s why|what happened next|` +
+		`the reason why|must see|watch:|photos?:|video:|!!|\?!|шок|вы не поверите|узнайте|вот почему|` +
+		`смотрите|видео:|фото:|неожиданно|сенсаци)`)
+	titleSeparators = []string{" | ", " — ", " – ", " - ", " :: "}
+)
+
+// titleScore title of article with its score
+type titleScore struct {
+	Title string
+	Score float64
+}
+
+// threadTitles return distinct titles of thread articles, best first. Titles are scored
+// by centrality in thread and overlap with thread key terms, clickbait, all caps,
+// site name suffixes and long titles are penalized
+func threadTitles(p []Article) []string {
+	if len(p) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(p))
+	if len(p) == 1 {
+		return append(res, p[0].Title)
+	}
+	lang := p[0].LangCode
+	tf := NewTFIDF(WithStopWords())
+	allwords := make([]string, 0)
+	for _, a := range p {
+		tf.AddLangDocs(lang, a.Words)
+		allwords = append(allwords, a.Words)
+	}
+	pooled := tf.CalLang(lang, strings.Join(allwords, " "))
+	keys := make(map[int32]bool)
+	for _, term := range strings.Fields(top(pooled, titleKeyTerms)) {
+		keys[vocab.ID(term)] = true
+	}
+
+	seen := make(map[string]bool)
+	scores := make([]titleScore, 0, len(p))
+	for _, a := range p {
+		title := strings.TrimSpace(a.Title)
+		if title == "" || seen[title] {
+			continue
+		}
+		seen[title] = true
+		tv := tf.CalLang(lang, strings.Join(bigwords(title, lang), " "))
+		overlap := 0
+		for _, id := range tv.IDs {
+			if keys[id] {
+				overlap++
+			}
+		}
+		score := Cosine(pooled, tv)
+		if len(tv.IDs) > 0 {
+			score += float64(overlap) / math.Min(float64(len(tv.IDs)), titleKeyTerms)
+		}
+		score -= titlePenalty(title, a)
+		scores = append(scores, titleScore{Title: title, Score: score})
+	}
+	// articles are sorted by centrality, so it breaks ties
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	for _, s := range scores {
+		res = append(res, s.Title)
+	}
+	return res
+}
+
+// titlePenalty return penalty for clickbait, all caps, site name suffix and long title
+func titlePenalty(title string, a Article) (penalty float64) {
+	penalty += titleClickbait * float64(len(clickbait.FindAllString(title, -1)))
+	letters, upper := 0, 0
+	for _, r := range title {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters >= 5 && float64(upper) > 0.6*float64(letters) {
+		penalty += titleAllCaps
+	}
+	if hasSiteSuffix(title, a) {
+		penalty += titleSiteName
+	}
+	if n := len([]rune(title)); n > titleMaxLen {
+		penalty += float64(n-titleMaxLen) / titleMaxLen
+	}
+	return
+}
+
+// hasSiteSuffix return true if title ends with separator and site name or domain
+func hasSiteSuffix(title string, a Article) bool {
+	lower := strings.ToLower(title)
+	site := strings.ToLower(strings.TrimSpace(a.SName))
+	host := strings.ToLower(a.Domain)
+	if i := strings.LastIndex(host, "."); i > 0 {
+		host = host[:i]
+	}
+	for _, sep := range titleSeparators {
+		i := strings.LastIndex(lower, sep)
+		if i < 0 {
+			continue
+		}
+		suffix := strings.TrimSpace(lower[i+len(sep):])
+		if suffix == "" {
+			continue
+		}
+		if suffix == site || (site != "" && strings.Contains(suffix, site)) ||
+			(host != "" && strings.Contains(strings.Replace(suffix, " ", "", -1), host)) {
+			return true
+		}
+	}
+	return false
+}